@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AuditEvents implements the "audit" command:
+//
+//	client audit [-ticket TICKET_ID] [-actor ACTOR] [-method RPC] [-since DURATION]
+//
+// It prints every matching change to the bookings, oldest first, and warns when
+// the server finds that the audit log was tampered with.
+func AuditEvents(client pb.BookingServiceClient, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	ticketID := flags.String("ticket", "", "only show changes to this ticket")
+	actor := flags.String("actor", "", "only show changes made by this caller")
+	method := flags.String("method", "", "only show changes made by this RPC, e.g. ModifyUserSeat")
+	since := flags.Duration("since", 0, "only show changes made within this duration, e.g. 24h")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	filter := &pb.AuditFilter{TicketId: *ticketID, Actor: *actor, Method: *method}
+	if *since > 0 {
+		filter.Since = timestamppb.New(time.Now().Add(-*since))
+	}
+
+	pageToken := ""
+	for {
+		response, err := client.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{Filter: filter, PageToken: pageToken})
+		if err != nil {
+			return err
+		}
+		if pageToken == "" && !response.ChainIntact {
+			fmt.Printf("WARNING : the audit log was tampered with, starting at event %d\n\n", response.FirstBrokenSequence)
+		}
+		for _, event := range response.Events {
+			fmt.Printf("#%-5d %s %-12s %-42s ticket %s : %s -> %s\n",
+				event.Sequence, event.Time.AsTime().Local().Format(time.DateTime), event.Actor, event.Method,
+				event.TicketId, describeSeat(event.Before), describeSeat(event.After))
+		}
+		if response.NextPageToken == "" {
+			return nil
+		}
+		pageToken = response.NextPageToken
+	}
+}
+
+// describeSeat summarizes the state of a ticket recorded in an audit event.
+func describeSeat(ticket *pb.Ticket) string {
+	switch {
+	case ticket == nil:
+		return "none"
+	case ticket.BoardedAt != nil:
+		return fmt.Sprintf("%s%d (boarded)", ticket.SeatSection, ticket.SeatNumber)
+	default:
+		return fmt.Sprintf("%s%d", ticket.SeatSection, ticket.SeatNumber)
+	}
+}
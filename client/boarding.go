@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// CheckIn implements the "checkin" command:
+//
+//	client checkin TICKET_ID
+func CheckIn(client pb.BookingServiceClient, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: client checkin TICKET_ID")
+	}
+	response, err := client.CheckIn(context.Background(), &pb.CheckInRequest{TicketId: args[0]})
+	if err != nil {
+		return err
+	}
+	printBoarded(response.Ticket)
+	return nil
+}
+
+// ScanTicket implements the "scan" command:
+//
+//	client scan PAYLOAD
+//
+// PAYLOAD is the signed ticket payload read from an e-ticket QR code.
+func ScanTicket(client pb.BookingServiceClient, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: client scan PAYLOAD")
+	}
+	response, err := client.ScanTicket(context.Background(), &pb.ScanTicketRequest{TicketPayload: args[0]})
+	if err != nil {
+		return err
+	}
+	if response.Validity == pb.TicketValidity_TICKET_VALIDITY_MOVED {
+		fmt.Println("Note : the passenger has moved since this ticket was issued")
+	}
+	printBoarded(response.Ticket)
+	return nil
+}
+
+func printBoarded(ticket *pb.Ticket) {
+	fmt.Printf("Boarded : %s %s, section %s, seat %d at %s\n",
+		ticket.User.GetFirstName(), ticket.User.GetLastName(), ticket.SeatSection, ticket.SeatNumber,
+		ticket.BoardedAt.AsTime().Local().Format("15:04:05"))
+}
+
+// BoardingReport implements the "boarding-report" command:
+//
+//	client boarding-report [-from STATION] [-to STATION]
+func BoardingReport(client pb.BookingServiceClient, args []string) error {
+	flags := flag.NewFlagSet("boarding-report", flag.ContinueOnError)
+	from := flags.String("from", "", "only report on the departure from this station")
+	to := flags.String("to", "", "only report on the departure to this station")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	response, err := client.GetBoardingReport(context.Background(), &pb.GetBoardingReportRequest{
+		Departure: &pb.Departure{From: *from, To: *to},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Boarded %d of %d passengers\n", response.Boarded, response.Booked)
+	if len(response.NoShows) > 0 {
+		fmt.Println("\nNo-shows :")
+	}
+	for _, ticket := range response.NoShows {
+		name := strings.TrimSpace(ticket.User.GetFirstName() + " " + ticket.User.GetLastName())
+		fmt.Printf("  %s%-3d %s <%s>\n", ticket.SeatSection, ticket.SeatNumber, name, ticket.User.GetEmail())
+	}
+	return nil
+}
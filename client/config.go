@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/KhetwalDevesh/book-my-seat/internal/configfile"
+)
+
+// clientConfig is assembled from, in increasing order of precedence, built-in
+// defaults, a YAML or TOML file, BOOKMYSEAT_* environment variables and the
+// flags given before the command.
+type clientConfig struct {
+	// Address is the host:port of the booking server.
+	Address string `yaml:"address" toml:"address"`
+	// Token is a bearer token sent with every call.
+	Token string `yaml:"token" toml:"token"`
+	// APIKey is sent with every call when no token is configured.
+	APIKey  string              `yaml:"api_key" toml:"api_key"`
+	TLS     clientTLSConfig     `yaml:"tls" toml:"tls"`
+	Tracing clientTracingConfig `yaml:"tracing" toml:"tracing"`
+}
+
+// clientTLSConfig configures the connection to the server. TLS is used when
+// Enabled is set or any of the files is configured.
+type clientTLSConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// CA holds the only CAs trusted to sign the server certificate; the system
+	// roots are used when it is empty.
+	CA string `yaml:"ca" toml:"ca"`
+	// Cert and Key are the client certificate presented to the server.
+	Cert       string `yaml:"cert" toml:"cert"`
+	Key        string `yaml:"key" toml:"key"`
+	ServerName string `yaml:"server_name" toml:"server_name"`
+}
+
+func defaultConfig() clientConfig {
+	return clientConfig{
+		Address: "book-my-seat:50051",
+		Tracing: clientTracingConfig{Exporter: "none", Endpoint: "localhost:4317"},
+	}
+}
+
+func (c *clientConfig) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: client [flags] [command [arguments]]\n\nCommands: import, export, receipt, verify, checkin, scan, boarding-report, audit.\nWithout a command the client shows an interactive menu.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.Address, "address", c.Address, "host:port of the booking server")
+	fs.StringVar(&c.Token, "token", c.Token, "bearer token sent with every call")
+	fs.StringVar(&c.APIKey, "api-key", c.APIKey, "API key sent with every call")
+	fs.BoolVar(&c.TLS.Enabled, "tls", c.TLS.Enabled, "connect over TLS (implied by -ca, -cert and -key)")
+	fs.StringVar(&c.TLS.CA, "ca", c.TLS.CA, "PEM file of the only CAs trusted to sign the server certificate (default: system roots)")
+	fs.StringVar(&c.TLS.Cert, "cert", c.TLS.Cert, "PEM encoded client certificate presented to the server")
+	fs.StringVar(&c.TLS.Key, "key", c.TLS.Key, "PEM encoded private key of -cert")
+	fs.StringVar(&c.TLS.ServerName, "server-name", c.TLS.ServerName, "name expected in the server certificate (default: the host dialled)")
+	fs.StringVar(&c.Tracing.Exporter, "tracing-exporter", c.Tracing.Exporter, "where spans are exported: none, stdout (written to stderr) or otlp")
+	fs.StringVar(&c.Tracing.Endpoint, "tracing-endpoint", c.Tracing.Endpoint, "host:port of the OTLP gRPC collector")
+	fs.BoolVar(&c.Tracing.Insecure, "tracing-insecure", c.Tracing.Insecure, "connect to the OTLP collector without TLS")
+	return fs
+}
+
+// loadConfig builds the configuration from args (without the program name), the
+// environment as seen through getenv and the file named by -config or
+// BOOKMYSEAT_CLIENT_CONFIG. It returns the arguments following the flags.
+func loadConfig(args []string, getenv func(string) string) (*clientConfig, []string, error) {
+	c := defaultConfig()
+	rest, err := configfile.Load(c.flagSet(), &c, func() { c = defaultConfig() }, configfile.EnvName("client-config"), args, getenv)
+	if err != nil {
+		return nil, nil, err
+	}
+	var errs []error
+	if c.Address == "" {
+		errs = append(errs, fmt.Errorf("address is required"))
+	}
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		errs = append(errs, fmt.Errorf("tls.cert and tls.key must be set together"))
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return &c, rest, nil
+}
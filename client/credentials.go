@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+)
+
+// callCredentials attaches the configured bearer token or API key to every call.
+type callCredentials struct {
+	token  string
+	apiKey string
+}
+
+func (c callCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if c.token != "" {
+		return map[string]string{"authorization": "Bearer " + c.token}, nil
+	}
+	return map[string]string{"x-api-key": c.apiKey}, nil
+}
+
+// RequireTransportSecurity is false so that credentials still work when the
+// client connects without TLS.
+func (c callCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// ExportManifest implements the "export" command:
+//
+//	client export [-format csv|json|pdf] [-from STATION] [-to STATION] [-o FILE]
+//
+// It writes the passenger manifest, sorted by section and seat, to FILE or stdout.
+func ExportManifest(client pb.BookingServiceClient, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "csv", "manifest format, csv, json or pdf")
+	from := flags.String("from", "", "only export the departure from this station")
+	to := flags.String("to", "", "only export the departure to this station")
+	output := flags.String("o", "", "file to write the manifest to (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	manifestFormat, ok := pb.ManifestFormat_value["MANIFEST_FORMAT_"+strings.ToUpper(*format)]
+	if !ok {
+		return fmt.Errorf("unsupported manifest format %q", *format)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	stream, err := client.ExportManifest(context.Background(), &pb.ExportManifestRequest{
+		Departure: &pb.Departure{From: *from, To: *to},
+		Format:    pb.ManifestFormat(manifestFormat),
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(response.Chunk); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// importRecord is one passenger of an import file. CSV files carry the same fields
+// as columns, named by a header row.
+type importRecord struct {
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	Email       string  `json:"email"`
+	SeatSection string  `json:"seat_section"`
+	SeatNumber  uint32  `json:"seat_number"`
+	TicketPrice float32 `json:"ticket_price"`
+}
+
+var importColumns = []string{"first_name", "last_name", "email", "seat_section", "seat_number", "ticket_price"}
+
+// ImportBookings implements the "import" command:
+//
+//	client import [-dry-run] [-format csv|jsonl] FILE
+//
+// It streams every row of FILE to the ImportBookings RPC and prints the errors
+// reported for each row, along with those of rows that could not be read. Nothing
+// is stored unless every row is valid.
+func ImportBookings(client pb.BookingServiceClient, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without storing any booking")
+	format := flags.String("format", "", "file format, csv or jsonl (default: guessed from the file extension)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: client import [-dry-run] [-format csv|jsonl] FILE")
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var rows []*pb.ImportRow
+	var unreadable []*pb.ImportRowError
+	switch *format {
+	case "csv":
+		rows, unreadable, err = readCSVRows(file)
+	case "json", "jsonl":
+		rows, unreadable, err = readJSONRows(file)
+	default:
+		return fmt.Errorf("unsupported import format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.ImportBookings(context.Background())
+	if err != nil {
+		return err
+	}
+	// The rows that could be read are still sent, as a dry run when some could
+	// not, so that the server reports on every one of them.
+	options := &pb.ImportOptions{DryRun: *dryRun || len(unreadable) > 0}
+	if err := stream.Send(&pb.ImportBookingsRequest{Payload: &pb.ImportBookingsRequest_Options{Options: options}}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := stream.Send(&pb.ImportBookingsRequest{Payload: &pb.ImportBookingsRequest_Row{Row: row}}); err != nil {
+			return err
+		}
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+
+	rowErrors := append(unreadable, response.Errors...)
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].RowNumber < rowErrors[j].RowNumber })
+	for _, rowError := range rowErrors {
+		fmt.Printf("row %d: %s\n", rowError.RowNumber, rowError.Message)
+	}
+	switch {
+	case len(rowErrors) > 0:
+		return fmt.Errorf("%d of %d rows are invalid, nothing was imported", len(rowErrors), len(unreadable)+int(response.RowsReceived))
+	case response.DryRun:
+		fmt.Printf("Dry run: all %d rows are valid\n", response.RowsReceived)
+	default:
+		fmt.Printf("Imported %d bookings\n", len(response.Tickets))
+	}
+	return nil
+}
+
+// readCSVRows reads the rows of a CSV file, returning an error for each row that
+// cannot be read instead of the row.
+func readCSVRows(r io.Reader) ([]*pb.ImportRow, []*pb.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading CSV header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("CSV header is missing the %q column", name)
+		}
+	}
+
+	var rows []*pb.ImportRow
+	var unreadable []*pb.ImportRowError
+	for line := 2; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return rows, unreadable, nil
+		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			unreadable = append(unreadable, &pb.ImportRowError{RowNumber: uint32(line), Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		seatNumber, err := strconv.ParseUint(fields[columns["seat_number"]], 10, 32)
+		if err != nil {
+			unreadable = append(unreadable, &pb.ImportRowError{RowNumber: uint32(line), Message: fmt.Sprintf("invalid seat number %q", fields[columns["seat_number"]])})
+			continue
+		}
+		ticketPrice, err := strconv.ParseFloat(fields[columns["ticket_price"]], 32)
+		if err != nil {
+			unreadable = append(unreadable, &pb.ImportRowError{RowNumber: uint32(line), Message: fmt.Sprintf("invalid ticket price %q", fields[columns["ticket_price"]])})
+			continue
+		}
+		row, err := toImportRow(uint32(line), importRecord{
+			FirstName:   fields[columns["first_name"]],
+			LastName:    fields[columns["last_name"]],
+			Email:       fields[columns["email"]],
+			SeatSection: fields[columns["seat_section"]],
+			SeatNumber:  uint32(seatNumber),
+			TicketPrice: float32(ticketPrice),
+		})
+		if err != nil {
+			unreadable = append(unreadable, &pb.ImportRowError{RowNumber: uint32(line), Message: err.Error()})
+			continue
+		}
+		rows = append(rows, row)
+	}
+}
+
+// readJSONRows reads the rows of a JSON lines file, returning an error for each
+// row that cannot be read instead of the row.
+func readJSONRows(r io.Reader) ([]*pb.ImportRow, []*pb.ImportRowError, error) {
+	var rows []*pb.ImportRow
+	var unreadable []*pb.ImportRowError
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record importRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			unreadable = append(unreadable, &pb.ImportRowError{RowNumber: uint32(line), Message: err.Error()})
+			continue
+		}
+		row, err := toImportRow(uint32(line), record)
+		if err != nil {
+			unreadable = append(unreadable, &pb.ImportRowError{RowNumber: uint32(line), Message: err.Error()})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, unreadable, scanner.Err()
+}
+
+func toImportRow(line uint32, record importRecord) (*pb.ImportRow, error) {
+	section, ok := pb.SeatSection_value[strings.ToUpper(strings.TrimSpace(record.SeatSection))]
+	if !ok {
+		return nil, fmt.Errorf("invalid seat section %q", record.SeatSection)
+	}
+	return &pb.ImportRow{
+		RowNumber: line,
+		User: &pb.User{
+			FirstName: strings.TrimSpace(record.FirstName),
+			LastName:  strings.TrimSpace(record.LastName),
+			Email:     strings.TrimSpace(record.Email),
+		},
+		SeatSection: pb.SeatSection(section),
+		SeatNumber:  record.SeatNumber,
+		TicketPrice: record.TicketPrice,
+	}, nil
+}
@@ -3,18 +3,18 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"log"
 	"os"
 	"strconv"
 	"strings"
 )
 
-var serverAddress = "book-my-seat:50051"
-
 func PurchaseTicket(client pb.BookingServiceClient) {
 	reader := bufio.NewReader(os.Stdin)
 	// Get input for User fields
@@ -82,12 +82,15 @@ func GetReceipt(client pb.BookingServiceClient) {
 	email, _ := reader.ReadString('\n')
 	email = strings.TrimSpace(email)
 
-	// Call the grpc method GetReceipt
-	response, err := client.GetReceipt(context.Background(), &pb.GetReceiptRequest{Email: email})
+	// Call the grpc method RenderReceipt for a printable receipt
+	response, err := client.RenderReceipt(context.Background(), &pb.RenderReceiptRequest{
+		Email:  email,
+		Format: pb.ReceiptFormat_RECEIPT_FORMAT_TEXT,
+	})
 	if err != nil {
-		log.Fatalf("Error calling GetReceipt : %v", err)
+		log.Fatalf("Error calling RenderReceipt : %v", err)
 	}
-	fmt.Printf("\nReceipt Details:\n\n%+v\n\n", response)
+	fmt.Printf("\n%s\n", response.Document)
 }
 
 func GetUsersAndSeatAllocated(client pb.BookingServiceClient) {
@@ -96,14 +99,25 @@ func GetUsersAndSeatAllocated(client pb.BookingServiceClient) {
 	seatSectionStr, _ := reader.ReadString('\n')
 	seatSectionStr = strings.TrimSpace(seatSectionStr)
 	seatSection := pb.SeatSection(pb.SeatSection_value[strings.ToUpper(seatSectionStr)])
-	// Call the grpc method GetUsersAndSeatAllocated
-	response, err := client.GetUsersAndSeatAllocated(context.Background(), &pb.GetUsersAndSeatAllocatedRequest{SeatSection: seatSection})
-	if err != nil {
-		log.Fatalf("Error calling GetUsersAndSeatAllocated : %v", err)
-	}
+	// Page through ListTickets so the allocation is printed in seat order
+	filter := &pb.TicketFilter{SeatSection: &seatSection}
 	fmt.Printf("\nUser and Seat allocated Details : \n")
-	for _, ticket := range response.SeatAllocated {
-		fmt.Printf("%+v\n", ticket)
+	pageToken := ""
+	for {
+		response, err := client.ListTickets(context.Background(), &pb.ListTicketsRequest{
+			Filter:    filter,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			log.Fatalf("Error calling ListTickets : %v", err)
+		}
+		for _, ticket := range response.Tickets {
+			fmt.Printf("%+v\n", ticket)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
 	}
 }
 
@@ -150,16 +164,98 @@ func ModifyUserSeat(client pb.BookingServiceClient) {
 	fmt.Printf("\nMessage : \n\n%+v\n\n", response)
 }
 
+func SearchPassengers(client pb.BookingServiceClient) {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Enter part of the name or email to search for : ")
+	query, _ := reader.ReadString('\n')
+	query = strings.TrimSpace(query)
+
+	// Page through the grpc method SearchPassengers
+	fmt.Printf("\nMatching Passengers : \n")
+	pageToken := ""
+	for {
+		response, err := client.SearchPassengers(context.Background(), &pb.SearchPassengersRequest{
+			Query:     query,
+			Match:     pb.PassengerMatch_PASSENGER_MATCH_SUBSTRING,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			log.Fatalf("Error calling SearchPassengers : %v", err)
+		}
+		for _, ticket := range response.Tickets {
+			fmt.Printf("%+v\n", ticket)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+}
+
+// RunCommand runs one of the non-interactive commands, e.g. "client import bookings.csv".
+func RunCommand(client pb.BookingServiceClient, command string, args []string) error {
+	switch command {
+	case "import":
+		return ImportBookings(client, args)
+	case "export":
+		return ExportManifest(client, args)
+	case "receipt":
+		return RenderReceipt(client, args)
+	case "verify":
+		return VerifyTicket(client, args)
+	case "checkin":
+		return CheckIn(client, args)
+	case "scan":
+		return ScanTicket(client, args)
+	case "boarding-report":
+		return BoardingReport(client, args)
+	case "audit":
+		return AuditEvents(client, args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
 func main() {
-	conn, err := grpc.Dial(serverAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cfg, args, err := loadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration : %v\n", err)
+	}
+	creds, err := transportCredentials(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Failed to set up TLS : %v\n", err)
+	}
+	stopTracing, err := setUpTracing(cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing : %v\n", err)
+	}
+	defer stopTracing()
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+	if cfg.Token != "" || cfg.APIKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(callCredentials{token: cfg.Token, apiKey: cfg.APIKey}))
+	}
+	conn, err := grpc.Dial(cfg.Address, dialOpts...)
 	if err != nil {
 		log.Fatalf("Failed to connect : %v\n", err)
 	}
 	defer conn.Close()
 	client := pb.NewBookingServiceClient(conn)
+	if len(args) > 0 {
+		if err := RunCommand(client, args[0], args[1:]); err != nil {
+			stopTracing()
+			log.Fatalf("Error running %s : %v", args[0], err)
+		}
+		return
+	}
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Println("Choose an option :\n 1 to purchase a Ticket\n 2 to view the details of the receipt of a User\n 3 to view the Users and seat they are allocated" +
-		"\n 4 to remove a user from the train\n 5 to modify a user's seat")
+		"\n 4 to remove a user from the train\n 5 to modify a user's seat\n 6 to search passengers by name or email")
 	inputOption, err := reader.ReadString('\n')
 	if err != nil {
 		log.Fatalf("Error inputting the option : %v\n", err)
@@ -181,6 +277,8 @@ func main() {
 		RemoveUser(client)
 	case 5:
 		ModifyUserSeat(client)
+	case 6:
+		SearchPassengers(client)
 	default:
 		log.Fatal("Please choose a valid option.")
 	}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// RenderReceipt implements the "receipt" command:
+//
+//	client receipt [-format text|html|pdf|e_ticket] [-o FILE] EMAIL
+//
+// Binary formats are saved to FILE, or to the file name suggested by the server.
+func RenderReceipt(client pb.BookingServiceClient, args []string) error {
+	flags := flag.NewFlagSet("receipt", flag.ContinueOnError)
+	format := flags.String("format", "text", "receipt format, text, html, pdf or e_ticket")
+	output := flags.String("o", "", "file to write the receipt to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: client receipt [-format text|html|pdf|e_ticket] [-o FILE] EMAIL")
+	}
+	receiptFormat, ok := pb.ReceiptFormat_value["RECEIPT_FORMAT_"+strings.ToUpper(*format)]
+	if !ok {
+		return fmt.Errorf("unsupported receipt format %q", *format)
+	}
+
+	response, err := client.RenderReceipt(context.Background(), &pb.RenderReceiptRequest{
+		Email:  flags.Arg(0),
+		Format: pb.ReceiptFormat(receiptFormat),
+	})
+	if err != nil {
+		return err
+	}
+	if *output == "" && strings.HasPrefix(response.ContentType, "text/plain") {
+		_, err := os.Stdout.Write(response.Document)
+		return err
+	}
+	if *output == "" {
+		*output = response.FileName
+	}
+	if err := os.WriteFile(*output, response.Document, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Receipt written to %s\n", *output)
+	return nil
+}
+
+// VerifyTicket implements the "verify" command:
+//
+//	client verify PAYLOAD
+//
+// PAYLOAD is the signed ticket payload read from an e-ticket QR code.
+func VerifyTicket(client pb.BookingServiceClient, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: client verify PAYLOAD")
+	}
+	response, err := client.VerifyTicket(context.Background(), &pb.VerifyTicketRequest{TicketPayload: args[0]})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Validity : %s\n", strings.TrimPrefix(response.Validity.String(), "TICKET_VALIDITY_"))
+	if response.Ticket != nil {
+		fmt.Printf("Current seat : Section %s, seat %d\n", response.Ticket.SeatSection, response.Ticket.SeatNumber)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// transportCredentials returns TLS credentials built from cfg, or plaintext
+// ones when TLS was not requested.
+func transportCredentials(cfg clientTLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled && cfg.CA == "" && cfg.Cert == "" && cfg.Key == "" {
+		return insecure.NewCredentials(), nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.ServerName}
+	if cfg.CA != "" {
+		raw, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(raw) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CA)
+		}
+	}
+	if cfg.Cert != "" || cfg.Key != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return credentials.NewTLS(config), nil
+}
@@ -0,0 +1,108 @@
+// Package configfile layers settings the way the server and the client both
+// take them: from, in increasing order of precedence, built-in defaults, a YAML
+// or TOML file, BOOKMYSEAT_* environment variables and command line flags.
+package configfile
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variable of every setting. The variable of
+// the -tls-cert flag, for example, is BOOKMYSEAT_TLS_CERT.
+const EnvPrefix = "BOOKMYSEAT_"
+
+// EnvName returns the environment variable of the setting bound to flag name.
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load parses args (without the program name) with fs, whose flags are bound to
+// the settings of v, and then layers onto the defaults of v the file named by
+// the -config flag or the configEnv variable, the environment as seen through
+// getenv and the flags given. The server and the client name their files with
+// variables of their own, as each rejects the settings of the other. reset
+// restores v to its defaults. Load returns the arguments following the flags.
+func Load(fs *flag.FlagSet, v interface{}, reset func(), configEnv string, args []string, getenv func(string) string) ([]string, error) {
+	configPath := fs.String("config", getenv(configEnv), "YAML (.yaml, .yml) or TOML (.toml) configuration file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Remember the flags given and start over, so that they can be applied on
+	// top of the file and the environment.
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	reset()
+
+	if *configPath != "" {
+		if err := Decode(*configPath, v); err != nil {
+			return nil, err
+		}
+	}
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		if value := getenv(EnvName(f.Name)); value != "" {
+			if err := f.Value.Set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", EnvName(f.Name), err))
+			}
+		}
+	})
+	for name, value := range explicit {
+		if err := fs.Set(name, value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return fs.Args(), nil
+}
+
+// Decode reads the YAML (.yaml, .yml) or TOML (.toml) file at path into v,
+// rejecting settings v has no field for.
+func Decode(path string, v interface{}) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading configuration: %v", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(raw), v)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, 0, len(undecoded))
+			for _, key := range undecoded {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+			return fmt.Errorf("parsing %s: unknown settings %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("configuration file %s must end in .yaml, .yml or .toml", path)
+	}
+	return nil
+}
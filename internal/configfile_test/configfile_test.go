@@ -0,0 +1,80 @@
+package configfile_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KhetwalDevesh/book-my-seat/internal/configfile"
+)
+
+type settings struct {
+	Address string `yaml:"address" toml:"address"`
+	Token   string `yaml:"token" toml:"token"`
+	Retries int    `yaml:"retries" toml:"retries"`
+}
+
+func load(t *testing.T, args []string, env map[string]string) (settings, []string, error) {
+	t.Helper()
+	s := settings{Address: "localhost:50051", Retries: 1}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&s.Address, "address", s.Address, "")
+	fs.StringVar(&s.Token, "token", s.Token, "")
+	fs.IntVar(&s.Retries, "retries", s.Retries, "")
+	rest, err := configfile.Load(fs, &s, func() { s = settings{Address: "localhost:50051", Retries: 1} }, configfile.EnvName("test-config"), args, func(name string) string { return env[name] })
+	return s, rest, err
+}
+
+func writeConfig(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Writing %s failed: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadLayersFileEnvironmentAndFlags(t *testing.T) {
+	path := writeConfig(t, "client.yaml", "address: file:1\ntoken: from-file\nretries: 3\n")
+	s, rest, err := load(t, []string{"-config", path, "-retries", "5", "import", "bookings.csv"}, map[string]string{"BOOKMYSEAT_TOKEN": "from-env"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	expected := settings{Address: "file:1", Token: "from-env", Retries: 5}
+	if s != expected {
+		t.Errorf("Expected %+v, got %+v", expected, s)
+	}
+	if strings.Join(rest, " ") != "import bookings.csv" {
+		t.Errorf("Expected the arguments after the flags, got %q", rest)
+	}
+
+	path = writeConfig(t, "client.toml", "address = \"toml:1\"\n")
+	if s, _, err := load(t, nil, map[string]string{"BOOKMYSEAT_TEST_CONFIG": path}); err != nil || s.Address != "toml:1" || s.Retries != 1 {
+		t.Errorf("Expected the file named by BOOKMYSEAT_TEST_CONFIG over the defaults, got %+v and %v", s, err)
+	}
+	if s, _, err := load(t, nil, map[string]string{"BOOKMYSEAT_CONFIG": path}); err != nil || s.Address != "localhost:50051" {
+		t.Errorf("Expected the file of another program to be ignored, got %+v and %v", s, err)
+	}
+}
+
+func TestLoadRejectsBadSettings(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		file     string
+		contents string
+		env      map[string]string
+		expected string
+	}{
+		{"unknown YAML key", "client.yaml", "adress: x\n", nil, "adress"},
+		{"unknown TOML key", "client.toml", "[tls]\nca = \"x\"\n", nil, "unknown settings tls"},
+		{"unknown extension", "client.json", "{}", nil, "must end in"},
+		{"bad environment value", "client.yaml", "", map[string]string{"BOOKMYSEAT_RETRIES": "many"}, "BOOKMYSEAT_RETRIES"},
+	} {
+		_, _, err := load(t, []string{"-config", writeConfig(t, test.file, test.contents)}, test.env)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error mentioning %q, got %v", test.name, test.expected, err)
+		}
+	}
+}
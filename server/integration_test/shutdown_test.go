@@ -0,0 +1,252 @@
+package integration_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var listeningOn = regexp.MustCompile(`Listening on ([0-9.]+:[0-9]+)`)
+
+// serverProcess is a server binary running in the background.
+type serverProcess struct {
+	cmd     *exec.Cmd
+	address string
+	mu      sync.Mutex
+	log     strings.Builder
+	exited  chan struct{}
+}
+
+// buildDir holds the server binary for the duration of the tests.
+var buildDir string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "book-my-seat-server")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Creating the build directory failed: %v\n", err)
+		os.Exit(1)
+	}
+	buildDir = dir
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// buildServer compiles the server once per test binary.
+var buildServer = sync.OnceValues(func() (string, error) {
+	binary := filepath.Join(buildDir, "server")
+	output, err := exec.Command("go", "build", "-o", binary, "github.com/KhetwalDevesh/book-my-seat/server").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, output)
+	}
+	return binary, nil
+})
+
+// startServer runs the server with args and waits until it listens.
+func startServer(t *testing.T, args ...string) *serverProcess {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("starts the server binary")
+	}
+	binary, err := buildServer()
+	if err != nil {
+		t.Fatalf("Building the server failed: %v", err)
+	}
+	process := &serverProcess{
+		cmd:    exec.Command(binary, append([]string{"-listen", "127.0.0.1:0", "-metrics-listen", "127.0.0.1:0", "-gateway-listen", "127.0.0.1:0", "-insecure-allow-anonymous"}, args...)...),
+		exited: make(chan struct{}),
+	}
+	stderr, err := process.cmd.StderrPipe()
+	if err != nil {
+		t.Fatalf("StderrPipe failed: %v", err)
+	}
+	if err := process.cmd.Start(); err != nil {
+		t.Fatalf("Starting the server failed: %v", err)
+	}
+	t.Cleanup(func() {
+		process.cmd.Process.Kill()
+		<-process.exited
+	})
+
+	listening := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			process.mu.Lock()
+			process.log.WriteString(scanner.Text() + "\n")
+			process.mu.Unlock()
+			if match := listeningOn.FindStringSubmatch(scanner.Text()); match != nil {
+				listening <- match[1]
+			}
+		}
+		process.cmd.Wait()
+		close(process.exited)
+	}()
+	select {
+	case process.address = <-listening:
+	case <-process.exited:
+		t.Fatalf("The server exited before listening:\n%s", process.output())
+	case <-time.After(10 * time.Second):
+		t.Fatalf("The server did not start listening:\n%s", process.output())
+	}
+	waitUntilServing(t, process)
+	return process
+}
+
+// waitUntilServing polls the health service until the booking service is SERVING.
+func waitUntilServing(t *testing.T, process *serverProcess) {
+	t.Helper()
+	conn, err := grpc.Dial(process.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		response, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: pb.BookingService_ServiceDesc.ServiceName})
+		if err == nil && response.Status == healthpb.HealthCheckResponse_SERVING {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("The server did not become ready (%v, %v):\n%s", response, err, process.output())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func (p *serverProcess) output() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.log.String()
+}
+
+// stop sends SIGTERM and returns the exit code.
+func (p *serverProcess) stop(t *testing.T) int {
+	t.Helper()
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signalling the server failed: %v", err)
+	}
+	select {
+	case <-p.exited:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("The server did not exit after SIGTERM:\n%s", p.output())
+	}
+	return p.cmd.ProcessState.ExitCode()
+}
+
+func dial(t *testing.T, address string) pb.BookingServiceClient {
+	t.Helper()
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBookingServiceClient(conn)
+}
+
+func TestSIGTERMDrainsAndSavesBookings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.json")
+	// The load comes from one address, so purchases must not be rate limited.
+	server := startServer(t, "-storage-backend", "file", "-storage-path", path, "-storage-flush-interval", "1h", "-layout-seats-per-section", "5000", "-rate-limit-methods", "")
+	client := dial(t, server.address)
+
+	// Keep purchasing from several goroutines until the server goes away,
+	// recording every booking it acknowledged.
+	var mu sync.Mutex
+	var booked []string
+	var wg sync.WaitGroup
+	enough := make(chan struct{})
+	var once sync.Once
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				email := fmt.Sprintf("passenger-%d-%d@example.com", worker, i)
+				_, err := client.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+					User:        &pb.User{FirstName: "Load", LastName: "Test", Email: email},
+					SeatSection: pb.SeatSection(worker % 2),
+					SeatNumber:  uint32(worker/2*1000 + i + 1),
+					TicketPrice: 20,
+				})
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				booked = append(booked, email)
+				if len(booked) == 200 {
+					once.Do(func() { close(enough) })
+				}
+				mu.Unlock()
+			}
+		}(worker)
+	}
+	select {
+	case <-enough:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("The server did not take 200 bookings in time:\n%s", server.output())
+	}
+
+	code := server.stop(t)
+	wg.Wait()
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d:\n%s", code, server.output())
+	}
+	if !strings.Contains(server.output(), "Drained all in-flight calls") {
+		t.Errorf("Expected the server to log the drain:\n%s", server.output())
+	}
+
+	snapshot, err := storage.NewFile(path).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Loading the saved bookings failed: %v", err)
+	}
+	for _, email := range booked {
+		if _, ok := snapshot.Tickets[email]; !ok {
+			t.Errorf("Acknowledged booking of %s was not saved", email)
+		}
+	}
+	if len(snapshot.Tickets) != len(booked) {
+		t.Errorf("Expected %d saved bookings, got %d", len(booked), len(snapshot.Tickets))
+	}
+}
+
+func TestSIGTERMCutsOffCallsAfterTimeout(t *testing.T) {
+	server := startServer(t, "-shutdown-timeout", "300ms")
+	client := dial(t, server.address)
+
+	// An import stream that is never closed keeps its call in flight.
+	stream, err := client.ImportBookings(context.Background())
+	if err != nil {
+		t.Fatalf("ImportBookings failed: %v", err)
+	}
+	if err := stream.Send(&pb.ImportBookingsRequest{Payload: &pb.ImportBookingsRequest_Options{Options: &pb.ImportOptions{DryRun: true}}}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	// Wait until the server has received the stream before signalling.
+	if _, err := client.ListTickets(context.Background(), &pb.ListTicketsRequest{}); err != nil {
+		t.Fatalf("ListTickets failed: %v", err)
+	}
+
+	if code := server.stop(t); code != 2 {
+		t.Errorf("Expected exit code 2 after the drain timeout, got %d:\n%s", code, server.output())
+	}
+	if _, err := stream.CloseAndRecv(); err == nil {
+		t.Errorf("Expected the in-flight import to be cut off, got %v", err)
+	}
+}
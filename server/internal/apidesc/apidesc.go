@@ -0,0 +1,76 @@
+// Package apidesc embeds the descriptors of the booking API, comments included,
+// so that tools such as grpcurl can discover its methods and message shapes from
+// the running server. booking.binpb is generated by `make generate`.
+package apidesc
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ReflectionServices are the services registered by RegisterReflection.
+var ReflectionServices = []string{
+	reflectionv1.ServerReflection_ServiceDesc.ServiceName,
+	reflectionv1alpha.ServerReflection_ServiceDesc.ServiceName,
+}
+
+//go:embed booking.binpb
+var descriptorSet []byte
+
+// DescriptorSet returns the embedded FileDescriptorSet of the booking protos and their imports.
+func DescriptorSet() []byte {
+	return descriptorSet
+}
+
+// Files returns the embedded file descriptors.
+var Files = sync.OnceValues(func() (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(descriptorSet, set); err != nil {
+		return nil, fmt.Errorf("parsing embedded descriptors: %v", err)
+	}
+	return protodesc.NewFiles(set)
+})
+
+// resolver looks descriptors up in the embedded set first and in the ones
+// linked into the binary otherwise, which covers services such as health and
+// reflection that are not part of booking.proto.
+type resolver struct {
+	embedded *protoregistry.Files
+}
+
+func (r resolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if file, err := r.embedded.FindFileByPath(path); err == nil {
+		return file, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r resolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if descriptor, err := r.embedded.FindDescriptorByName(name); err == nil {
+		return descriptor, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// RegisterReflection registers both versions of the server reflection service
+// on server, answering from the embedded descriptors.
+func RegisterReflection(server reflection.GRPCServer) error {
+	embedded, err := Files()
+	if err != nil {
+		return err
+	}
+	opts := reflection.ServerOptions{Services: server, DescriptorResolver: resolver{embedded: embedded}}
+	reflectionv1.RegisterServerReflectionServer(server, reflection.NewServerV1(opts))
+	reflectionv1alpha.RegisterServerReflectionServer(server, reflection.NewServer(opts))
+	return nil
+}
@@ -0,0 +1,155 @@
+package apidesc_test
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/apidesc"
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestEmbeddedDescriptorsMatchStubs fails when a booking proto changed without
+// `make generate` refreshing booking.binpb.
+func TestEmbeddedDescriptorsMatchStubs(t *testing.T) {
+	files, err := apidesc.Files()
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	for _, stub := range []protoreflect.FileDescriptor{pb.File_booking_service_v1_booking_proto, bookingv2.File_bookmyseat_booking_v2_booking_proto} {
+		embedded, err := files.FindFileByPath(stub.Path())
+		if err != nil {
+			t.Fatalf("%s is not embedded: %v", stub.Path(), err)
+		}
+		got := protodesc.ToFileDescriptorProto(embedded)
+		if got.SourceCodeInfo == nil {
+			t.Errorf("Expected the embedded descriptors of %s to keep the proto comments", stub.Path())
+		}
+		got.SourceCodeInfo = nil
+		want := protodesc.ToFileDescriptorProto(stub)
+		want.SourceCodeInfo = nil
+		if !proto.Equal(got, want) {
+			t.Errorf("booking.binpb is out of date for %s, run make generate", stub.Path())
+		}
+	}
+}
+
+func dialReflection(t *testing.T) reflectionpb.ServerReflection_ServerReflectionInfoClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	bookings := api.NewBookingServiceServer()
+	pb.RegisterBookingServiceServer(grpcServer, bookings)
+	bookingv2.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceV2Server(bookings))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	if err := apidesc.RegisterReflection(grpcServer); err != nil {
+		t.Fatalf("RegisterReflection failed: %v", err)
+	}
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo failed: %v", err)
+	}
+	return stream
+}
+
+func ask(t *testing.T, stream reflectionpb.ServerReflection_ServerReflectionInfoClient, req *reflectionpb.ServerReflectionRequest) *reflectionpb.ServerReflectionResponse {
+	t.Helper()
+	if err := stream.Send(req); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if errorResponse := response.GetErrorResponse(); errorResponse != nil {
+		t.Fatalf("Reflection error: %s", errorResponse.ErrorMessage)
+	}
+	return response
+}
+
+func TestReflectionListsServices(t *testing.T) {
+	stream := dialReflection(t)
+	response := ask(t, stream, &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	var services []string
+	for _, service := range response.GetListServicesResponse().Service {
+		services = append(services, service.Name)
+	}
+	sort.Strings(services)
+	want := []string{"BookingService.BookingService", "bookmyseat.booking.v2.BookingService", "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection"}
+	if len(services) != len(want) {
+		t.Fatalf("Expected services %v, got %v", want, services)
+	}
+	for i := range want {
+		if services[i] != want[i] {
+			t.Errorf("Expected services %v, got %v", want, services)
+			break
+		}
+	}
+}
+
+func TestReflectionDescribesBookingService(t *testing.T) {
+	stream := dialReflection(t)
+	response := ask(t, stream, &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "BookingService.BookingService"},
+	})
+	files := map[string]*descriptorpb.FileDescriptorProto{}
+	for _, raw := range response.GetFileDescriptorResponse().FileDescriptorProto {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, file); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		files[file.GetName()] = file
+	}
+	booking, ok := files["booking-service/v1/booking.proto"]
+	if !ok {
+		t.Fatalf("Expected booking.proto in the response, got %v", files)
+	}
+	if _, ok := files["google/protobuf/timestamp.proto"]; !ok {
+		t.Errorf("Expected the imported timestamp.proto to be sent along")
+	}
+	methods := map[string]bool{}
+	for _, method := range booking.Service[0].Method {
+		methods[method.GetName()] = true
+	}
+	for _, method := range pb.BookingService_ServiceDesc.Methods {
+		if !methods[method.MethodName] {
+			t.Errorf("Expected method %s to be described", method.MethodName)
+		}
+	}
+	if booking.SourceCodeInfo == nil {
+		t.Errorf("Expected the described file to carry its comments")
+	}
+
+	// Services outside booking.proto resolve from the linked-in descriptors.
+	response = ask(t, stream, &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "grpc.health.v1.Health"},
+	})
+	if len(response.GetFileDescriptorResponse().FileDescriptorProto) == 0 {
+		t.Errorf("Expected the health service to be described")
+	}
+}
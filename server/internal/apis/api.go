@@ -1,17 +1,278 @@
 package apis
 
-import pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+import (
+	"context"
+	"sync"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/audit"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/helpers"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/notify"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// tracer records the spans of the booking service. It follows whichever tracer
+// provider is installed globally, even one installed after startup.
+var tracer = otel.Tracer("github.com/KhetwalDevesh/book-my-seat/server/internal/apis")
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Layout describes the train being booked.
+type Layout struct {
+	// From and To are the stations the train runs between.
+	From string
+	To   string
+	// SeatsPerSection is the number of seats in each section of the train.
+	SeatsPerSection uint32
+}
+
+// DefaultLayout is the train booked when no layout is configured.
+var DefaultLayout = Layout{From: "London", To: "France", SeatsPerSection: 50}
+
+// Limits bound the work a single request can ask for.
+type Limits struct {
+	// MaxImportRows bounds the number of rows a single ImportBookings stream may carry.
+	MaxImportRows int
+	// MaxPageSize bounds the page size of ListTickets and SearchPassengers.
+	MaxPageSize int
+	// MaxTicketsPerCaller bounds the tickets a caller without a staff role may
+	// hold at once, whoever the passengers. 0 leaves it unbounded.
+	MaxTicketsPerCaller int
+}
+
+// DefaultLimits are used when no limits are configured.
+var DefaultLimits = Limits{MaxImportRows: 10000, MaxPageSize: 100}
 
 type BookingServiceServer struct {
 	pb.BookingServiceServer
-	Tickets     map[string]pb.Ticket            // emailId is the key here
-	SeatMapping map[string]map[string]pb.Ticket // seat_section is the key to outer map, emailId is the key to inner map
+	mu          sync.RWMutex                     // guards the maps and indexes below
+	Tickets     map[string]*pb.Ticket            // normalized emailId is the key here
+	SeatMapping map[string]map[string]*pb.Ticket // seat_section is the key to outer map, normalized emailId is the key to inner map
+	ticketIDs   map[string]string                // ticket ID is the key, normalized emailId is the value
+	buyers      map[string]string                // ticket ID is the key, the caller that bought it is the value
+	held        map[string]int                   // caller is the key, the number of its tickets in buyers is the value
+	cancelled   map[string]struct{}              // IDs of removed tickets
+	passengers  *passengerIndex                  // name and email index over Tickets, used by SearchPassengers
+	audit       *audit.Log                       // every change made to the bookings
+	outbox      *events.Outbox                   // events waiting to be delivered to webhooks, nil if none are configured
+	notifier    *notify.Notifier                 // tells passengers about changes to their bookings, nil if disabled
+	emails      helpers.EmailNormalizer          // derives the Tickets key from a user supplied email
+	signer      *signing.Signer                  // signs every stored ticket
+	layout      Layout                           // the train being booked
+	limits      Limits                           // per-request limits
+	store       storage.Store                    // where Flush saves and Restore loads the bookings
+	version     uint64                           // incremented by every change to the bookings, guarded by mu
+	flushMu     sync.Mutex                       // serializes Flush
+	flushed     uint64                           // version last saved to store, guarded by flushMu
+	outboxSaved uint64                           // outbox changes last saved to store, guarded by flushMu
+}
+
+// Option configures optional behaviour of a BookingServiceServer.
+type Option func(*BookingServiceServer)
+
+// WithEmailNormalizer sets how user supplied emails are turned into ticket keys.
+func WithEmailNormalizer(normalizer helpers.EmailNormalizer) Option {
+	return func(s *BookingServiceServer) {
+		s.emails = normalizer
+	}
+}
+
+// WithTicketSigner sets the key used to sign tickets. Without it the server
+// signs with a key generated by NewBookingServiceServer, which panics if the
+// key cannot be generated; servers that must report that failure, such as the
+// one started by main, pass a signer of their own.
+func WithTicketSigner(signer *signing.Signer) Option {
+	return func(s *BookingServiceServer) {
+		s.signer = signer
+	}
+}
+
+// WithLayout sets the train being booked.
+func WithLayout(layout Layout) Option {
+	return func(s *BookingServiceServer) {
+		s.layout = layout
+	}
+}
+
+// WithLimits sets the per-request limits.
+func WithLimits(limits Limits) Option {
+	return func(s *BookingServiceServer) {
+		s.limits = limits
+	}
+}
+
+// WithStore sets where bookings are saved by Flush and loaded from by Restore.
+// Without it bookings only live in memory.
+func WithStore(store storage.Store) Option {
+	return func(s *BookingServiceServer) {
+		s.store = store
+	}
+}
+
+// WithOutbox sets the outbox that events about changes to the bookings are
+// published to. Its contents are saved and restored with the bookings.
+func WithOutbox(outbox *events.Outbox) Option {
+	return func(s *BookingServiceServer) {
+		s.outbox = outbox
+	}
+}
+
+// WithNotifier sets the notifier telling passengers about purchases, seat
+// changes and cancellations of their tickets.
+func WithNotifier(notifier *notify.Notifier) Option {
+	return func(s *BookingServiceServer) {
+		s.notifier = notifier
+	}
 }
 
 // NewBookingServiceServer creates a new instance of BookingServiceServer with initialized maps.
-func NewBookingServiceServer() *BookingServiceServer {
-	return &BookingServiceServer{
-		Tickets:     make(map[string]pb.Ticket),
-		SeatMapping: make(map[string]map[string]pb.Ticket),
+func NewBookingServiceServer(opts ...Option) *BookingServiceServer {
+	s := &BookingServiceServer{
+		Tickets:     make(map[string]*pb.Ticket),
+		SeatMapping: make(map[string]map[string]*pb.Ticket),
+		ticketIDs:   make(map[string]string),
+		buyers:      make(map[string]string),
+		held:        make(map[string]int),
+		cancelled:   make(map[string]struct{}),
+		passengers:  newPassengerIndex(),
+		audit:       audit.NewLog(nil),
+		layout:      DefaultLayout,
+		limits:      DefaultLimits,
+		store:       storage.Memory{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.signer == nil {
+		signer, err := signing.GenerateSigner()
+		if err != nil {
+			panic(err)
+		}
+		s.signer = signer
+	}
+	return s
+}
+
+// userKey normalizes email into the key used for Tickets and SeatMapping. Every
+// handler accepting an email must go through it so lookups agree with bookings.
+func (s *BookingServiceServer) userKey(email string) (string, error) {
+	key, err := s.emails.Normalize(email)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return key, nil
+}
+
+// Restore replaces the bookings with those saved in the store. Tickets keep the
+// signatures they were issued with.
+func (s *BookingServiceServer) Restore(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	ctx, span := tracer.Start(ctx, "storage.Load")
+	snapshot, err := s.store.Load(ctx)
+	if err == nil {
+		span.SetAttributes(attribute.Int("bookmyseat.tickets", len(snapshot.Tickets)))
+	}
+	endSpan(span, err)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Tickets = make(map[string]*pb.Ticket)
+	s.SeatMapping = make(map[string]map[string]*pb.Ticket)
+	s.ticketIDs = make(map[string]string)
+	s.buyers = make(map[string]string)
+	s.held = make(map[string]int)
+	s.cancelled = make(map[string]struct{})
+	s.passengers = newPassengerIndex()
+	for key, ticket := range snapshot.Tickets {
+		s.indexTicket(key, ticket)
+	}
+	for id, caller := range snapshot.Buyers {
+		if _, exists := s.ticketIDs[id]; exists {
+			s.buyers[id] = caller
+			s.held[caller]++
+		}
+	}
+	for _, id := range snapshot.Cancelled {
+		s.cancelled[id] = struct{}{}
+	}
+	s.audit = audit.NewLog(snapshot.Audit)
+	if err := s.audit.Verify(); err != nil {
+		logging.FromContext(ctx).Error("the saved audit log was tampered with", "error", err)
+	}
+	if s.outbox != nil {
+		s.outbox.Restore(snapshot.Outbox, snapshot.DeadLetters)
+		s.outboxSaved = s.outbox.Changes()
+	}
+	s.flushed = s.version
+	return nil
+}
+
+// Flush saves the bookings to the store if they, or the outbox, changed since
+// the last flush.
+func (s *BookingServiceServer) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.RLock()
+	version := s.version
+	var outboxChanges uint64
+	if s.outbox != nil {
+		outboxChanges = s.outbox.Changes()
+	}
+	if version == s.flushed && outboxChanges == s.outboxSaved {
+		s.mu.RUnlock()
+		return nil
+	}
+	snapshot := &storage.Snapshot{Tickets: make(map[string]*pb.Ticket, len(s.Tickets))}
+	for key, ticket := range s.Tickets {
+		snapshot.Tickets[key] = proto.Clone(ticket).(*pb.Ticket)
+	}
+	if len(s.buyers) > 0 {
+		snapshot.Buyers = make(map[string]string, len(s.buyers))
+		for id, caller := range s.buyers {
+			snapshot.Buyers[id] = caller
+		}
+	}
+	for id := range s.cancelled {
+		snapshot.Cancelled = append(snapshot.Cancelled, id)
+	}
+	// Appended events are never modified, so they can be shared.
+	snapshot.Audit = append([]*pb.AuditEvent(nil), s.audit.Events()...)
+	if s.outbox != nil {
+		snapshot.Outbox, snapshot.DeadLetters = s.outbox.Snapshot()
+	}
+	s.mu.RUnlock()
+
+	ctx, span := tracer.Start(ctx, "storage.Save", trace.WithAttributes(attribute.Int("bookmyseat.tickets", len(snapshot.Tickets))))
+	err := s.store.Save(ctx, snapshot)
+	endSpan(span, err)
+	if err != nil {
+		return err
 	}
+	s.flushed = version
+	s.outboxSaved = outboxChanges
+	return nil
 }
@@ -0,0 +1,62 @@
+package apis
+
+import (
+	"context"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fullMethod returns the full gRPC method name of a BookingService RPC.
+func fullMethod(name string) string {
+	return "/" + pb.BookingService_ServiceDesc.ServiceName + "/" + name
+}
+
+// Policy lists the roles allowed to call each RPC of both versions of
+// BookingService. Passengers are further restricted to their own tickets by the
+// handlers themselves.
+var Policy = auth.Policy{
+	fullMethod("PurchaseTicket"):           {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	fullMethod("GetReceipt"):               {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	fullMethod("RenderReceipt"):            {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	fullMethod("VerifyTicket"):             {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("ListTickets"):              {auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("SearchPassengers"):         {auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("ImportBookings"):           {auth.RoleAgent, auth.RoleAdmin},
+	fullMethod("ExportManifest"):           {auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("CheckIn"):                  {auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("ScanTicket"):               {auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("GetBoardingReport"):        {auth.RoleConductor, auth.RoleAdmin},
+	fullMethod("GetUsersAndSeatAllocated"): {auth.RoleAdmin},
+	fullMethod("RemoveUser"):               {auth.RoleAdmin},
+	fullMethod("ModifyUserSeat"):           {auth.RoleAdmin},
+	fullMethod("ListAuditEvents"):          {auth.RoleAdmin},
+
+	fullMethodV2("GetDeparture"):   {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethodV2("ListDepartures"): {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethodV2("ListSeats"):      {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethodV2("CreateTicket"):   {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	fullMethodV2("GetTicket"):      {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	fullMethodV2("ListTickets"):    {auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	fullMethodV2("UpdateTicket"):   {auth.RoleAdmin},
+	fullMethodV2("DeleteTicket"):   {auth.RoleAdmin},
+}
+
+// authorizeUser returns a PermissionDenied error when a caller without a staff
+// role acts on the booking stored under userKey without it being their own.
+// Calls that did not go through authentication, such as in-process ones, are
+// not restricted.
+func (s *BookingServiceServer) authorizeUser(ctx context.Context, userKey string) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok || identity.IsStaff() {
+		return nil
+	}
+	if identity.Email != "" {
+		if own, err := s.emails.Normalize(identity.Email); err == nil && own == userKey {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "passengers can only access their own tickets")
+}
@@ -0,0 +1,95 @@
+package apis
+
+import (
+	"context"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CheckIn marks the passenger holding ticket_id as boarded.
+func (s *BookingServiceServer) CheckIn(ctx context.Context, req *pb.CheckInRequest) (*pb.CheckInResponse, error) {
+	if req.TicketId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "ticket ID is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticket, err := s.board(ctx, "CheckIn", req.TicketId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CheckInResponse{Ticket: ticket}, nil
+}
+
+// ScanTicket checks in the passenger presenting a signed e-ticket payload. A
+// passenger who has since moved seats boards on their current seat.
+func (s *BookingServiceServer) ScanTicket(ctx context.Context, req *pb.ScanTicketRequest) (*pb.ScanTicketResponse, error) {
+	claims, err := s.signer.Verify(req.TicketPayload)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticket, err := s.board(ctx, "ScanTicket", claims.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	validity := pb.TicketValidity_TICKET_VALIDITY_VALID
+	if ticket.SeatSection.String() != claims.SeatSection || ticket.SeatNumber != claims.SeatNumber {
+		validity = pb.TicketValidity_TICKET_VALIDITY_MOVED
+	}
+	return &pb.ScanTicketResponse{Validity: validity, Ticket: ticket}, nil
+}
+
+// board records that the holder of ticketID has boarded, auditing it as a change
+// made by the RPC named method, and returns a copy of the ticket. Callers must
+// hold s.mu for writing.
+func (s *BookingServiceServer) board(ctx context.Context, method string, ticketID string) (*pb.Ticket, error) {
+	userKey, exists := s.ticketIDs[ticketID]
+	if !exists {
+		if _, cancelled := s.cancelled[ticketID]; cancelled {
+			return nil, status.Errorf(codes.FailedPrecondition, "ticket %s was cancelled", ticketID)
+		}
+		return nil, status.Errorf(codes.NotFound, "ticket %s not found", ticketID)
+	}
+	ticket := s.Tickets[userKey]
+	if ticket.BoardedAt != nil {
+		return nil, status.Errorf(codes.AlreadyExists, "ticket %s was already checked in at %s", ticketID, ticket.BoardedAt.AsTime().Format("15:04:05"))
+	}
+	boarded := proto.Clone(ticket).(*pb.Ticket)
+	boarded.BoardedAt = timestamppb.Now()
+	s.indexTicket(userKey, boarded)
+	s.version++
+	s.recordChange(ctx, fullMethod(method), ticket, boarded)
+	return proto.Clone(boarded).(*pb.Ticket), nil
+}
+
+// GetBoardingReport counts the passengers of a departure who have boarded and lists the no-shows.
+func (s *BookingServiceServer) GetBoardingReport(ctx context.Context, req *pb.GetBoardingReportRequest) (*pb.GetBoardingReportResponse, error) {
+	filter := &pb.TicketFilter{Departure: req.Departure}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	response := &pb.GetBoardingReportResponse{}
+	for _, ticket := range s.Tickets {
+		if !matchesFilter(ticket, filter) {
+			continue
+		}
+		response.Booked++
+		if ticket.BoardedAt != nil {
+			response.Boarded++
+		} else {
+			response.NoShows = append(response.NoShows, proto.Clone(ticket).(*pb.Ticket))
+		}
+	}
+	sortTickets(response.NoShows, pb.TicketOrder_TICKET_ORDER_SEAT, false)
+	return response, nil
+}
@@ -5,152 +5,228 @@ import (
 	"fmt"
 	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"regexp"
+	"strings"
 )
 
 func (s *BookingServiceServer) PurchaseTicket(ctx context.Context, req *pb.PurchaseTicketRequest) (*pb.PurchaseTicketResponse, error) {
-	seatSection := req.SeatSection
-	seatNumber := req.SeatNumber
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Check if user already purchased a ticket
-	_, exists := s.Tickets[req.User.Email]
-	if exists {
-		return nil, fmt.Errorf("User already booked a ticket")
+	ticket, err := s.purchase(ctx, fullMethod("PurchaseTicket"), req.User, req.SeatSection, req.SeatNumber, req.TicketPrice)
+	if err != nil {
+		return nil, err
 	}
+	return &pb.PurchaseTicketResponse{Ticket: proto.Clone(ticket).(*pb.Ticket)}, nil
+}
 
-	// Check if the section matches the available sections in the train
-	if seatSection != pb.SeatSection_A && seatSection != pb.SeatSection_B {
-		return nil, fmt.Errorf("invalid seat section")
-	}
+// allocateSeat issues userKey a ticket for the given seat, if it is free, and
+// stores it. Callers must hold s.mu for writing.
+func (s *BookingServiceServer) allocateSeat(ctx context.Context, userKey string, user *pb.User, seatSection pb.SeatSection, seatNumber uint32, price float32) (ticket *pb.Ticket, err error) {
+	_, span := tracer.Start(ctx, "allocateSeat", trace.WithAttributes(seatAttributes(seatSection, seatNumber)...))
+	defer func() { endSpan(span, err) }()
 
-	// Ensure that the map for the specific seat section is initialized
-	if s.SeatMapping[seatSection.String()] == nil {
-		s.SeatMapping[seatSection.String()] = make(map[string]pb.Ticket)
+	if s.seatOccupied(seatSection, seatNumber) {
+		return nil, status.Errorf(codes.FailedPrecondition, "Seat already occupied, choose some other")
 	}
 
-	seatAlreadyOccupied := false
-	for _, ticket := range s.SeatMapping[seatSection.String()] {
-		if ticket.SeatNumber == seatNumber {
-			seatAlreadyOccupied = true
-		}
+	ticket, err = s.newTicket(user, seatSection, seatNumber, price)
+	if err != nil {
+		return nil, err
 	}
-	if seatAlreadyOccupied {
-		return nil, fmt.Errorf("Seat already occupied, choose some other")
+
+	// Store the ticket and seat allocation
+	s.storeTicket(userKey, ticket)
+	span.SetAttributes(attribute.String("bookmyseat.ticket_id", ticket.Id))
+	return ticket, nil
+}
+
+// seatAttributes describes a seat on a span.
+func seatAttributes(seatSection pb.SeatSection, seatNumber uint32) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("bookmyseat.seat_section", seatSection.String()),
+		attribute.Int("bookmyseat.seat_number", int(seatNumber)),
 	}
+}
 
+// newTicket issues a ticket for user on the given seat, assigning the ticket and the user unique IDs.
+func (s *BookingServiceServer) newTicket(user *pb.User, seatSection pb.SeatSection, seatNumber uint32, price float32) (*pb.Ticket, error) {
+	phone, locale, err := contactDetails(user)
+	if err != nil {
+		return nil, err
+	}
 	// Generate a unique ID for the user
 	userID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate user ID: %v", err)
 	}
+	ticketID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ticket ID: %v", err)
+	}
 
-	// Create a new ticket with the unique ID
-	ticket := &pb.Ticket{
-		From: "London",
-		To:   "France",
+	// Create a new ticket with the unique IDs
+	return &pb.Ticket{
+		Id:   ticketID.String(),
+		From: s.layout.From,
+		To:   s.layout.To,
 		User: &pb.User{
 			Id:        uint64(userID.ID()),
-			FirstName: req.User.FirstName,
-			LastName:  req.User.LastName,
-			Email:     req.User.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Email:     strings.TrimSpace(user.Email),
+			Phone:     phone,
+			Locale:    locale,
 		},
-		PricePaid:   req.TicketPrice,
+		PricePaid:   price,
 		SeatSection: seatSection,
 		SeatNumber:  seatNumber,
+	}, nil
+}
+
+// phonePattern matches E.164 phone numbers.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// contactDetails validates the optional phone number and locale of user and
+// returns them in canonical form.
+func contactDetails(user *pb.User) (phone, locale string, err error) {
+	phone = strings.Join(strings.Fields(user.Phone), "")
+	if phone != "" && !phonePattern.MatchString(phone) {
+		return "", "", status.Errorf(codes.InvalidArgument, "phone number must be in E.164 format, e.g. +447700900123")
+	}
+	if user.Locale != "" {
+		tag, err := language.Parse(user.Locale)
+		if err != nil {
+			return "", "", status.Errorf(codes.InvalidArgument, "invalid locale %q", user.Locale)
+		}
+		locale = tag.String()
 	}
+	return phone, locale, nil
+}
 
-	// Store the ticket and seat allocation
-	s.Tickets[req.User.Email] = *ticket
-	s.SeatMapping[seatSection.String()][req.User.Email] = *ticket
-	return &pb.PurchaseTicketResponse{Ticket: ticket}, nil
+// seatOccupied reports whether anyone holds seatNumber in seatSection. Callers must hold s.mu.
+func (s *BookingServiceServer) seatOccupied(seatSection pb.SeatSection, seatNumber uint32) bool {
+	for _, ticket := range s.SeatMapping[seatSection.String()] {
+		if ticket.SeatNumber == seatNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// storeTicket signs ticket and records it under userKey in every map and index.
+// Callers must hold s.mu for writing.
+func (s *BookingServiceServer) storeTicket(userKey string, ticket *pb.Ticket) {
+	s.signer.Sign(ticket)
+	s.indexTicket(userKey, ticket)
+	s.version++
+}
+
+// indexTicket records ticket under userKey in every map and index. Callers must
+// hold s.mu for writing.
+func (s *BookingServiceServer) indexTicket(userKey string, ticket *pb.Ticket) {
+	// Ensure that the map for the specific seat section is initialized
+	if s.SeatMapping[ticket.SeatSection.String()] == nil {
+		s.SeatMapping[ticket.SeatSection.String()] = make(map[string]*pb.Ticket)
+	}
+	s.Tickets[userKey] = ticket
+	s.SeatMapping[ticket.SeatSection.String()][userKey] = ticket
+	s.ticketIDs[ticket.Id] = userKey
+	s.passengers.add(userKey, ticket.User)
+}
+
+// deleteTicket removes the ticket stored under userKey from every map and index.
+// Callers must hold s.mu for writing.
+func (s *BookingServiceServer) deleteTicket(userKey string) {
+	ticket, exists := s.Tickets[userKey]
+	if !exists {
+		return
+	}
+	delete(s.Tickets, userKey)
+	for _, section := range []string{"A", "B"} {
+		delete(s.SeatMapping[section], userKey)
+	}
+	delete(s.ticketIDs, ticket.Id)
+	if caller, exists := s.buyers[ticket.Id]; exists {
+		delete(s.buyers, ticket.Id)
+		if s.held[caller]--; s.held[caller] == 0 {
+			delete(s.held, caller)
+		}
+	}
+	s.cancelled[ticket.Id] = struct{}{}
+	s.passengers.remove(userKey)
+	s.version++
 }
 
 func (s *BookingServiceServer) GetReceipt(ctx context.Context, req *pb.GetReceiptRequest) (*pb.GetReceiptResponse, error) {
-	email := req.Email
-	ticket := s.Tickets[email]
-	return &pb.GetReceiptResponse{Ticket: &pb.Ticket{
-		From:        ticket.From,
-		To:          ticket.To,
-		User:        ticket.User,
-		PricePaid:   ticket.PricePaid,
-		SeatSection: ticket.SeatSection,
-		SeatNumber:  ticket.SeatNumber,
-	}}, nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	email, err := s.userKey(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeUser(ctx, email); err != nil {
+		return nil, err
+	}
+	ticket, exists := s.Tickets[email]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "no ticket booked for %s", req.Email)
+	}
+	return &pb.GetReceiptResponse{Ticket: proto.Clone(ticket).(*pb.Ticket)}, nil
 }
 
 func (s *BookingServiceServer) GetUsersAndSeatAllocated(ctx context.Context, req *pb.GetUsersAndSeatAllocatedRequest) (*pb.GetUsersAndSeatAllocatedResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	section := req.SeatSection.String()
 	usersAndSeatAllocated := s.SeatMapping[section]
 
 	pbUsersAndSeatAllocated := make(map[string]*pb.Ticket)
 	for email, ticket := range usersAndSeatAllocated {
-		pbUsersAndSeatAllocated[email] = &pb.Ticket{
-			From:        ticket.From,
-			To:          ticket.To,
-			User:        ticket.User,
-			PricePaid:   ticket.PricePaid,
-			SeatSection: ticket.SeatSection,
-			SeatNumber:  ticket.SeatNumber,
-		}
+		pbUsersAndSeatAllocated[email] = proto.Clone(ticket).(*pb.Ticket)
 	}
 	return &pb.GetUsersAndSeatAllocatedResponse{SeatAllocated: pbUsersAndSeatAllocated}, nil
 }
 
 func (s *BookingServiceServer) RemoveUser(ctx context.Context, req *pb.RemoveUserRequest) (*pb.RemoveUserResponse, error) {
-	_, exists := s.Tickets[req.Email]
-	if !exists {
-		return nil, fmt.Errorf("User not found")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email, err := s.userKey(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := s.Tickets[email]; !exists {
+		return nil, status.Errorf(codes.NotFound, "User not found")
 	}
 
 	// Remove user and seat allocation
-	delete(s.Tickets, req.Email)
-	for _, section := range []string{"A", "B"} {
-		delete(s.SeatMapping[section], req.Email)
-	}
+	s.cancel(ctx, fullMethod("RemoveUser"), email)
 	return &pb.RemoveUserResponse{Msg: "User removed successfully"}, nil
 }
 
 func (s *BookingServiceServer) ModifyUserSeat(ctx context.Context, req *pb.ModifyUserSeatRequest) (*pb.ModifyUserSeatResponse, error) {
-	newSeatSection := req.NewSeatSection
-	newSeatNumber := req.NewSeatNumber
-	userEmail := req.Email
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	_, exists := s.Tickets[userEmail]
-	if !exists {
-		return nil, fmt.Errorf("User not found!")
-	}
-
-	// Check if the section matches the available sections in the train
-	if newSeatSection != pb.SeatSection_A && newSeatSection != pb.SeatSection_B {
-		return nil, fmt.Errorf("invalid seat section")
-	}
-
-	// Check if there are available seats, 50 taken for each section, just to put some limit
-	if newSeatNumber > 50 {
-		return nil, fmt.Errorf("Invalid seat number, only 50 seats exists")
+	userEmail, err := s.userKey(req.Email)
+	if err != nil {
+		return nil, err
 	}
-
-	// Ensure that the map for the specific seat section is initialized
-	if s.SeatMapping[newSeatSection.String()] == nil {
-		s.SeatMapping[newSeatSection.String()] = make(map[string]pb.Ticket)
+	if _, exists := s.Tickets[userEmail]; !exists {
+		return nil, status.Errorf(codes.NotFound, "User not found!")
 	}
 
-	seatAlreadyOccupied := false
-	for _, ticket := range s.SeatMapping[newSeatSection.String()] {
-		if ticket.SeatNumber == newSeatNumber {
-			seatAlreadyOccupied = true
-		}
-	}
-	if seatAlreadyOccupied {
-		return nil, fmt.Errorf("Seat already occupied, choose some other")
+	if _, _, err := s.moveSeat(ctx, fullMethod("ModifyUserSeat"), userEmail, req.NewSeatSection, req.NewSeatNumber); err != nil {
+		return nil, err
 	}
-
-	ticket, _ := s.Tickets[userEmail]
-	// delete the old instance of seat allocated
-	delete(s.SeatMapping[ticket.SeatSection.String()], userEmail)
-	ticket.SeatSection = newSeatSection
-	ticket.SeatNumber = newSeatNumber
-	s.Tickets[userEmail] = ticket
-	s.SeatMapping[newSeatSection.String()][userEmail] = ticket
-
 	return &pb.ModifyUserSeatResponse{Msg: "User Seat successfully modified"}, nil
 }
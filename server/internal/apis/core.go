@@ -0,0 +1,151 @@
+package apis
+
+import (
+	"context"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/ratelimit"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// The operations below are the booking core that the handlers of every API
+// version adapt their requests onto. Each one validates a change, applies it
+// and records it in the audit log, the event outbox and the passenger's
+// notifications, so that a booking looks the same whichever version made it.
+// method is the full gRPC method making the change, as audited. Callers must
+// hold s.mu for writing.
+
+// seatSections are the sections of the train, in order.
+var seatSections = []pb.SeatSection{pb.SeatSection_A, pb.SeatSection_B}
+
+// checkSeat returns an InvalidArgument error unless the seat exists on the train.
+func (s *BookingServiceServer) checkSeat(seatSection pb.SeatSection, seatNumber uint32) error {
+	if seatSection != pb.SeatSection_A && seatSection != pb.SeatSection_B {
+		return status.Errorf(codes.InvalidArgument, "invalid seat section")
+	}
+	if seatNumber == 0 || seatNumber > s.layout.SeatsPerSection {
+		return status.Errorf(codes.InvalidArgument, "Invalid seat number, only %d seats exists", s.layout.SeatsPerSection)
+	}
+	return nil
+}
+
+// purchase books the seat for user, who must not hold a ticket yet.
+func (s *BookingServiceServer) purchase(ctx context.Context, method string, user *pb.User, seatSection pb.SeatSection, seatNumber uint32, price float32) (*pb.Ticket, error) {
+	userKey, err := s.checkPurchase(ctx, user, seatSection, seatNumber, price, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.allocateSeat(ctx, userKey, user, seatSection, seatNumber, price)
+	if err != nil {
+		return nil, err
+	}
+	if caller := ratelimit.Caller(ctx); caller != "" {
+		s.buyers[ticket.Id] = caller
+		s.held[caller]++
+	}
+	s.recordChange(ctx, method, nil, ticket)
+	s.publish(events.TicketPurchased, nil, ticket)
+	s.notifyPassenger(events.TicketPurchased, nil, ticket)
+	logging.FromContext(ctx).Info("ticket purchased",
+		"ticket_id", ticket.Id, "section", seatSection.String(), "seat", seatNumber, "price", ticket.PricePaid)
+	return ticket, nil
+}
+
+// checkPurchase returns the key user's ticket would be stored under, or an
+// error unless the caller of ctx may buy user the seat at price. pending counts
+// the tickets the caller is buying in the same request ahead of this one, which
+// count against the tickets a caller may hold. It does not check that the seat
+// is free.
+func (s *BookingServiceServer) checkPurchase(ctx context.Context, user *pb.User, seatSection pb.SeatSection, seatNumber uint32, price float32, pending int) (string, error) {
+	userKey, err := s.userKey(user.GetEmail())
+	if err != nil {
+		return "", err
+	}
+	if err := s.authorizeUser(ctx, userKey); err != nil {
+		return "", err
+	}
+	if _, exists := s.Tickets[userKey]; exists {
+		return "", status.Errorf(codes.AlreadyExists, "User already booked a ticket")
+	}
+	if err := s.checkSeat(seatSection, seatNumber); err != nil {
+		return "", err
+	}
+	if price < 0 {
+		return "", status.Errorf(codes.InvalidArgument, "ticket price cannot be negative")
+	}
+	if err := s.checkTicketsHeld(ctx, ratelimit.Caller(ctx), pending); err != nil {
+		return "", err
+	}
+	return userKey, nil
+}
+
+// checkTicketsHeld returns a ResourceExhausted error when caller, lacking a
+// staff role, already holds as many tickets as a caller may, counting pending
+// tickets not stored yet.
+func (s *BookingServiceServer) checkTicketsHeld(ctx context.Context, caller string, pending int) error {
+	if s.limits.MaxTicketsPerCaller == 0 || caller == "" {
+		return nil
+	}
+	if identity, ok := auth.FromContext(ctx); ok && identity.IsStaff() {
+		return nil
+	}
+	held := s.held[caller] + pending
+	if held >= s.limits.MaxTicketsPerCaller {
+		return status.Errorf(codes.ResourceExhausted, "you already hold %d tickets on this departure, the most allowed", held)
+	}
+	return nil
+}
+
+// moveSeat moves the passenger holding the ticket stored under userKey to
+// another seat and returns the ticket before and after the move.
+func (s *BookingServiceServer) moveSeat(ctx context.Context, method string, userKey string, seatSection pb.SeatSection, seatNumber uint32) (before, after *pb.Ticket, err error) {
+	if err := s.checkSeat(seatSection, seatNumber); err != nil {
+		return nil, nil, err
+	}
+
+	_, span := tracer.Start(ctx, "reallocateSeat", trace.WithAttributes(seatAttributes(seatSection, seatNumber)...))
+	if s.seatOccupied(seatSection, seatNumber) {
+		err := status.Errorf(codes.FailedPrecondition, "Seat already occupied, choose some other")
+		endSpan(span, err)
+		return nil, nil, err
+	}
+
+	// Store the moved ticket as a new message rather than changing the stored
+	// one, which responses already sent may still be reading.
+	before = s.Tickets[userKey]
+	after = proto.Clone(before).(*pb.Ticket)
+	after.SeatSection = seatSection
+	after.SeatNumber = seatNumber
+	// delete the old instance of seat allocated
+	delete(s.SeatMapping[before.SeatSection.String()], userKey)
+	s.storeTicket(userKey, after)
+	endSpan(span, nil)
+	s.recordChange(ctx, method, before, after)
+	s.publish(events.SeatChanged, before, after)
+	s.notifyPassenger(events.SeatChanged, before, after)
+	logging.FromContext(ctx).Info("seat changed",
+		"ticket_id", after.Id,
+		"from_section", before.SeatSection.String(), "from_seat", before.SeatNumber,
+		"to_section", seatSection.String(), "to_seat", seatNumber)
+	return before, after, nil
+}
+
+// cancel removes the ticket stored under userKey, freeing its seat, and
+// returns it.
+func (s *BookingServiceServer) cancel(ctx context.Context, method string, userKey string) *pb.Ticket {
+	ticket := s.Tickets[userKey]
+	s.deleteTicket(userKey)
+	s.recordChange(ctx, method, ticket, nil)
+	s.publish(events.TicketCancelled, ticket, nil)
+	s.notifyPassenger(events.TicketCancelled, ticket, nil)
+	logging.FromContext(ctx).Info("ticket cancelled",
+		"ticket_id", ticket.Id, "section", ticket.SeatSection.String(), "seat", ticket.SeatNumber)
+	return ticket
+}
@@ -0,0 +1,46 @@
+package apis
+
+import (
+	"encoding/json"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ticketChange is the data of the events published about a ticket. Before is
+// null for purchases and After for cancellations.
+type ticketChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// publish adds an event of type t about a change to a ticket to the outbox,
+// before and after being as for recordChange. Callers must hold s.mu for
+// writing, so that the event is saved in the same snapshot as the change.
+func (s *BookingServiceServer) publish(t events.Type, before, after *pb.Ticket) {
+	if s.outbox == nil {
+		return
+	}
+	event := events.Event{Type: t}
+	var change ticketChange
+	if before != nil {
+		change.Before, _ = protojson.Marshal(before)
+		event.TicketID = before.Id
+	}
+	if after != nil {
+		change.After, _ = protojson.Marshal(after)
+		event.TicketID = after.Id
+	}
+	event.Data, _ = json.Marshal(change)
+	s.outbox.Publish(event)
+}
+
+// notifyPassenger tells the passenger of a ticket about a change to it, before
+// and after being as for recordChange.
+func (s *BookingServiceServer) notifyPassenger(t events.Type, before, after *pb.Ticket) {
+	if s.notifier == nil {
+		return
+	}
+	s.notifier.Notify(t, before, after)
+}
@@ -0,0 +1,97 @@
+package apis
+
+import (
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/render"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// exportChunkSize is the size of the manifest chunks streamed to the client.
+const exportChunkSize = 32 * 1024
+
+func (s *BookingServiceServer) ExportManifest(req *pb.ExportManifestRequest, stream pb.BookingService_ExportManifestServer) error {
+	if _, ok := pb.ManifestFormat_name[int32(req.Format)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported manifest format %v", req.Format)
+	}
+
+	manifest := &render.Manifest{From: s.layout.From, To: s.layout.To, GeneratedAt: time.Now()}
+	if departure := req.Departure; departure != nil {
+		if departure.From != "" {
+			manifest.From = departure.From
+		}
+		if departure.To != "" {
+			manifest.To = departure.To
+		}
+	}
+
+	// Copy the matching tickets so rendering, which may be slow for large
+	// trains, does not hold the lock.
+	filter := &pb.TicketFilter{Departure: req.Departure}
+	s.mu.RLock()
+	for _, ticket := range s.Tickets {
+		if matchesFilter(ticket, filter) {
+			manifest.Tickets = append(manifest.Tickets, proto.Clone(ticket).(*pb.Ticket))
+		}
+	}
+	s.mu.RUnlock()
+	sortTickets(manifest.Tickets, pb.TicketOrder_TICKET_ORDER_SEAT, false)
+
+	writer := &chunkWriter{stream: stream, contentType: render.ManifestContentType(req.Format)}
+	if err := manifest.Write(writer, req.Format); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// chunkWriter streams everything written to it as ExportManifestResponse chunks
+// of at most exportChunkSize bytes.
+type chunkWriter struct {
+	stream      pb.BookingService_ExportManifestServer
+	contentType string
+	buf         []byte
+	sent        bool
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, exportChunkSize)
+		}
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *chunkWriter) flush() error {
+	response := &pb.ExportManifestResponse{Chunk: w.buf}
+	if !w.sent {
+		response.ContentType = w.contentType
+	}
+	if err := w.stream.Send(response); err != nil {
+		return err
+	}
+	w.sent = true
+	w.buf = nil
+	return nil
+}
+
+// Close sends any buffered bytes, or an empty first chunk carrying the content
+// type if nothing was written at all.
+func (w *chunkWriter) Close() error {
+	if len(w.buf) == 0 && w.sent {
+		return nil
+	}
+	return w.flush()
+}
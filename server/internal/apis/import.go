@@ -0,0 +1,116 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ImportBookings receives a batch of bookings and stores them all or none of them:
+// every row is validated against the current bookings and the rest of the batch
+// before anything is written, so a bad row never leaves SeatMapping half updated.
+func (s *BookingServiceServer) ImportBookings(stream pb.BookingService_ImportBookingsServer) error {
+	options := &pb.ImportOptions{}
+	var rows []*pb.ImportRow
+	for received := 0; ; received++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch payload := req.Payload.(type) {
+		case *pb.ImportBookingsRequest_Options:
+			if received > 0 {
+				return status.Errorf(codes.InvalidArgument, "import options must be sent before any row")
+			}
+			options = payload.Options
+		case *pb.ImportBookingsRequest_Row:
+			if len(rows) == s.limits.MaxImportRows {
+				return status.Errorf(codes.InvalidArgument, "an import cannot contain more than %d rows", s.limits.MaxImportRows)
+			}
+			rows = append(rows, payload.Row)
+		default:
+			return status.Errorf(codes.InvalidArgument, "empty import message")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := stream.Context()
+	response := &pb.ImportBookingsResponse{DryRun: options.DryRun, RowsReceived: int32(len(rows))}
+	var valid []*pb.ImportRow
+	batchUsers := make(map[string]uint32)
+	batchSeats := make(map[string]uint32)
+	for _, row := range rows {
+		ticket, err := s.validateImportRow(ctx, row, len(valid), batchUsers, batchSeats)
+		if err != nil {
+			response.Errors = append(response.Errors, &pb.ImportRowError{RowNumber: row.RowNumber, Message: status.Convert(err).Message()})
+			continue
+		}
+		valid = append(valid, row)
+		response.Tickets = append(response.Tickets, ticket)
+	}
+
+	if len(response.Errors) > 0 || options.DryRun {
+		return stream.SendAndClose(response)
+	}
+	// Every row passed the checks of purchase against the bookings as they are
+	// now, and s.mu is held, so booking them all succeeds.
+	response.Tickets = response.Tickets[:0]
+	for _, row := range valid {
+		ticket, err := s.purchase(ctx, fullMethod("ImportBookings"), row.User, row.SeatSection, row.SeatNumber, row.TicketPrice)
+		if err != nil {
+			return err
+		}
+		response.Tickets = append(response.Tickets, proto.Clone(ticket).(*pb.Ticket))
+	}
+	response.Committed = true
+	return stream.SendAndClose(response)
+}
+
+// validateImportRow checks row against the stored bookings and the rows of the
+// batch seen so far, of which pending are valid, with the checks of purchase. If
+// row is valid it is recorded in batchUsers and batchSeats and a preview of its
+// ticket, without IDs, is returned. Callers must hold s.mu.
+func (s *BookingServiceServer) validateImportRow(ctx context.Context, row *pb.ImportRow, pending int, batchUsers, batchSeats map[string]uint32) (*pb.Ticket, error) {
+	if row.User == nil {
+		return nil, fmt.Errorf("missing passenger details")
+	}
+	if row.User.FirstName == "" || row.User.LastName == "" {
+		return nil, fmt.Errorf("first and last name are required")
+	}
+	key, err := s.checkPurchase(ctx, row.User, row.SeatSection, row.SeatNumber, row.TicketPrice, pending)
+	if err != nil {
+		return nil, err
+	}
+	if other, exists := batchUsers[key]; exists {
+		return nil, fmt.Errorf("%s is also booked by row %d", key, other)
+	}
+	if s.seatOccupied(row.SeatSection, row.SeatNumber) {
+		return nil, fmt.Errorf("seat %s%d is already occupied", row.SeatSection, row.SeatNumber)
+	}
+	seat := fmt.Sprintf("%s%d", row.SeatSection, row.SeatNumber)
+	if other, exists := batchSeats[seat]; exists {
+		return nil, fmt.Errorf("seat %s is also assigned by row %d", seat, other)
+	}
+
+	ticket, err := s.newTicket(row.User, row.SeatSection, row.SeatNumber, row.TicketPrice)
+	if err != nil {
+		return nil, err
+	}
+	// The IDs are only assigned when the row is booked, so a preview carries
+	// none that a client could mistake for those of the stored ticket.
+	ticket.Id = ""
+	ticket.User.Id = 0
+	batchUsers[key] = row.RowNumber
+	batchSeats[seat] = row.RowNumber
+	return ticket, nil
+}
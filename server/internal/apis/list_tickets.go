@@ -0,0 +1,154 @@
+package apis
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/helpers"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultPageSize is used when a request does not ask for a page size.
+const defaultPageSize = 20
+
+func (s *BookingServiceServer) ListTickets(ctx context.Context, req *pb.ListTicketsRequest) (*pb.ListTicketsResponse, error) {
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size cannot be negative")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]*pb.Ticket, 0)
+	for _, ticket := range s.Tickets {
+		if matchesFilter(ticket, req.Filter) {
+			matched = append(matched, ticket)
+		}
+	}
+	sortTickets(matched, req.OrderBy, req.Descending)
+
+	query := &pb.ListTicketsRequest{Filter: req.Filter, OrderBy: req.OrderBy, Descending: req.Descending}
+	start, end, next, err := s.page(req.PageToken, query, len(matched), req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	response := &pb.ListTicketsResponse{TotalSize: int32(len(matched)), NextPageToken: next}
+	for _, ticket := range matched[start:end] {
+		response.Tickets = append(response.Tickets, proto.Clone(ticket).(*pb.Ticket))
+	}
+	return response, nil
+}
+
+// pageSize returns the number of results to return for the requested page
+// size, applying the default and the configured maximum.
+func (s *BookingServiceServer) pageSize(requested int32) int {
+	pageSize := int(requested)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > s.limits.MaxPageSize {
+		pageSize = s.limits.MaxPageSize
+	}
+	return pageSize
+}
+
+// page returns the bounds of the page of count results that token points at,
+// holding up to the requested page size, and the token of the next page. query
+// identifies the results, see helpers.Fingerprint.
+func (s *BookingServiceServer) page(token string, query proto.Message, count int, requested int32) (start, end int, next string, err error) {
+	start, end, next, err = helpers.Page(token, query, count, s.pageSize(requested))
+	if err != nil {
+		return 0, 0, "", status.Errorf(codes.InvalidArgument, "invalid page token: %v", err)
+	}
+	return start, end, next, nil
+}
+
+func matchesFilter(ticket *pb.Ticket, filter *pb.TicketFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.SeatSection != nil && ticket.SeatSection != *filter.SeatSection {
+		return false
+	}
+	if ticket.SeatNumber < filter.MinSeatNumber {
+		return false
+	}
+	if filter.MaxSeatNumber != 0 && ticket.SeatNumber > filter.MaxSeatNumber {
+		return false
+	}
+	if filter.NamePrefix != "" {
+		prefix := strings.ToLower(filter.NamePrefix)
+		if !strings.HasPrefix(strings.ToLower(ticket.User.GetFirstName()), prefix) &&
+			!strings.HasPrefix(strings.ToLower(ticket.User.GetLastName()), prefix) {
+			return false
+		}
+	}
+	if filter.EmailPrefix != "" && !strings.HasPrefix(strings.ToLower(ticket.User.GetEmail()), strings.ToLower(filter.EmailPrefix)) {
+		return false
+	}
+	if departure := filter.Departure; departure != nil {
+		if departure.From != "" && !strings.EqualFold(ticket.From, departure.From) {
+			return false
+		}
+		if departure.To != "" && !strings.EqualFold(ticket.To, departure.To) {
+			return false
+		}
+	}
+	if filter.MinPrice != nil && ticket.PricePaid < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && ticket.PricePaid > *filter.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// sortTickets orders tickets by the requested key. Ties are broken by section, seat
+// and email so that the order, and therefore pagination, is stable between calls.
+func sortTickets(tickets []*pb.Ticket, order pb.TicketOrder, descending bool) {
+	bySeat := func(a, b *pb.Ticket) int {
+		if a.SeatSection != b.SeatSection {
+			return int(a.SeatSection) - int(b.SeatSection)
+		}
+		if a.SeatNumber != b.SeatNumber {
+			if a.SeatNumber < b.SeatNumber {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.User.GetEmail(), b.User.GetEmail())
+	}
+	compare := func(a, b *pb.Ticket) int {
+		switch order {
+		case pb.TicketOrder_TICKET_ORDER_LAST_NAME:
+			if c := strings.Compare(strings.ToLower(a.User.GetLastName()), strings.ToLower(b.User.GetLastName())); c != 0 {
+				return c
+			}
+			if c := strings.Compare(strings.ToLower(a.User.GetFirstName()), strings.ToLower(b.User.GetFirstName())); c != 0 {
+				return c
+			}
+		case pb.TicketOrder_TICKET_ORDER_EMAIL:
+			if c := strings.Compare(a.User.GetEmail(), b.User.GetEmail()); c != 0 {
+				return c
+			}
+		case pb.TicketOrder_TICKET_ORDER_PRICE:
+			if a.PricePaid != b.PricePaid {
+				if a.PricePaid < b.PricePaid {
+					return -1
+				}
+				return 1
+			}
+		}
+		return bySeat(a, b)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		if descending {
+			return compare(tickets[i], tickets[j]) > 0
+		}
+		return compare(tickets[i], tickets[j]) < 0
+	})
+}
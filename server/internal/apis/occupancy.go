@@ -0,0 +1,36 @@
+package apis
+
+import (
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// SectionOccupancy summarizes the bookings of one section of the train.
+type SectionOccupancy struct {
+	Section pb.SeatSection
+	// Sold and Free count the booked and bookable seats of the section.
+	Sold uint32
+	Free uint32
+	// Revenue is the sum of the prices paid for the section's tickets.
+	Revenue float64
+}
+
+// Occupancy summarizes the bookings of every section, in section order.
+func (s *BookingServiceServer) Occupancy() []SectionOccupancy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sections := []pb.SeatSection{pb.SeatSection_A, pb.SeatSection_B}
+	occupancy := make([]SectionOccupancy, 0, len(sections))
+	for _, section := range sections {
+		summary := SectionOccupancy{Section: section}
+		for _, ticket := range s.SeatMapping[section.String()] {
+			summary.Sold++
+			summary.Revenue += float64(ticket.PricePaid)
+		}
+		if summary.Sold < s.layout.SeatsPerSection {
+			summary.Free = s.layout.SeatsPerSection - summary.Sold
+		}
+		occupancy = append(occupancy, summary)
+	}
+	return occupancy
+}
@@ -0,0 +1,135 @@
+package apis
+
+import (
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// maxGramLength is the longest n-gram stored in the index. Queries longer than
+// this are answered by intersecting the posting lists of their n-grams.
+const maxGramLength = 3
+
+// passengerIndex is an n-gram index over the lower-cased first name, last name
+// and email of every ticket holder, keyed the same way as Tickets. It lets
+// SearchPassengers find candidates without scanning every booking.
+type passengerIndex struct {
+	grams map[string]map[string]struct{} // n-gram is the key to outer map, ticket key is the key to inner map
+	terms map[string][]string            // ticket key is the key, indexed terms are the values
+}
+
+func newPassengerIndex() *passengerIndex {
+	return &passengerIndex{
+		grams: make(map[string]map[string]struct{}),
+		terms: make(map[string][]string),
+	}
+}
+
+func passengerTerms(user *pb.User) []string {
+	return []string{
+		strings.ToLower(user.GetFirstName()),
+		strings.ToLower(user.GetLastName()),
+		strings.ToLower(user.GetEmail()),
+	}
+}
+
+// add indexes the passenger holding the ticket stored under key, replacing any
+// previous entry for that key.
+func (idx *passengerIndex) add(key string, user *pb.User) {
+	idx.remove(key)
+	terms := passengerTerms(user)
+	idx.terms[key] = terms
+	for _, term := range terms {
+		for _, gram := range ngrams(term) {
+			if idx.grams[gram] == nil {
+				idx.grams[gram] = make(map[string]struct{})
+			}
+			idx.grams[gram][key] = struct{}{}
+		}
+	}
+}
+
+func (idx *passengerIndex) remove(key string) {
+	for _, term := range idx.terms[key] {
+		for _, gram := range ngrams(term) {
+			delete(idx.grams[gram], key)
+			if len(idx.grams[gram]) == 0 {
+				delete(idx.grams, gram)
+			}
+		}
+	}
+	delete(idx.terms, key)
+}
+
+// search returns the keys of passengers with a term starting with (or, when
+// substring is set, containing) query. Matching is case-insensitive.
+func (idx *passengerIndex) search(query string, substring bool) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	// Intersect the posting lists of the query's n-grams, starting from the smallest.
+	grams := queryGrams(query)
+	smallest := idx.grams[grams[0]]
+	for _, gram := range grams[1:] {
+		if len(idx.grams[gram]) < len(smallest) {
+			smallest = idx.grams[gram]
+		}
+	}
+	candidates := make([]string, 0, len(smallest))
+	for key := range smallest {
+		inAll := true
+		for _, gram := range grams {
+			if _, ok := idx.grams[gram][key]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			candidates = append(candidates, key)
+		}
+	}
+
+	var keys []string
+	for _, key := range candidates {
+		for _, term := range idx.terms[key] {
+			if (substring && strings.Contains(term, query)) || strings.HasPrefix(term, query) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// ngrams returns every distinct substring of term up to maxGramLength runes long.
+func ngrams(term string) []string {
+	runes := []rune(term)
+	seen := make(map[string]struct{})
+	var grams []string
+	for i := range runes {
+		for n := 1; n <= maxGramLength && i+n <= len(runes); n++ {
+			gram := string(runes[i : i+n])
+			if _, ok := seen[gram]; !ok {
+				seen[gram] = struct{}{}
+				grams = append(grams, gram)
+			}
+		}
+	}
+	return grams
+}
+
+// queryGrams splits query into the overlapping n-grams that any term containing
+// it must also contain.
+func queryGrams(query string) []string {
+	runes := []rune(query)
+	if len(runes) <= maxGramLength {
+		return []string{query}
+	}
+	grams := make([]string, 0, len(runes)-maxGramLength+1)
+	for i := 0; i+maxGramLength <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+maxGramLength]))
+	}
+	return grams
+}
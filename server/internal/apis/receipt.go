@@ -0,0 +1,52 @@
+package apis
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/render"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *BookingServiceServer) RenderReceipt(ctx context.Context, req *pb.RenderReceiptRequest) (*pb.RenderReceiptResponse, error) {
+	if _, ok := pb.ReceiptFormat_name[int32(req.Format)]; !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported receipt format %v", req.Format)
+	}
+	email, err := s.userKey(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeUser(ctx, email); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	ticket, exists := s.Tickets[email]
+	if exists {
+		ticket = proto.Clone(ticket).(*pb.Ticket)
+	}
+	s.mu.RUnlock()
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "no ticket booked for %s", req.Email)
+	}
+
+	if len(ticket.Signature) == 0 {
+		s.signer.Sign(ticket)
+	}
+	receipt := &render.Receipt{Ticket: ticket, Payload: signing.Payload(ticket)}
+	var document bytes.Buffer
+	if err := receipt.Write(&document, req.Format); err != nil {
+		return nil, status.Errorf(codes.Internal, "rendering receipt: %v", err)
+	}
+	contentType, extension := render.ReceiptContentType(req.Format)
+	return &pb.RenderReceiptResponse{
+		Document:      document.Bytes(),
+		ContentType:   contentType,
+		FileName:      "ticket-" + ticket.Id + "." + extension,
+		TicketPayload: receipt.Payload,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package apis
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *BookingServiceServer) SearchPassengers(ctx context.Context, req *pb.SearchPassengersRequest) (*pb.SearchPassengersResponse, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "search query cannot be empty")
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size cannot be negative")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := s.passengers.search(req.Query, req.Match == pb.PassengerMatch_PASSENGER_MATCH_SUBSTRING)
+	matched := make([]*pb.Ticket, 0, len(keys))
+	for _, key := range keys {
+		if ticket, exists := s.Tickets[key]; exists {
+			matched = append(matched, ticket)
+		}
+	}
+	sortTickets(matched, pb.TicketOrder_TICKET_ORDER_LAST_NAME, false)
+
+	query := &pb.SearchPassengersRequest{Query: strings.ToLower(strings.TrimSpace(req.Query)), Match: req.Match}
+	start, end, next, err := s.page(req.PageToken, query, len(matched), req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	response := &pb.SearchPassengersResponse{NextPageToken: next}
+	for _, ticket := range matched[start:end] {
+		response.Tickets = append(response.Tickets, proto.Clone(ticket).(*pb.Ticket))
+	}
+	return response, nil
+}
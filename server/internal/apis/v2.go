@@ -0,0 +1,321 @@
+package apis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// BookingServiceV2Server serves bookmyseat.booking.v2.BookingService over the
+// bookings of a BookingServiceServer, so that both versions of the API see and
+// change the same tickets.
+type BookingServiceV2Server struct {
+	bookingv2.UnimplementedBookingServiceServer
+	core *BookingServiceServer
+}
+
+// NewBookingServiceV2Server serves the bookings of core through the v2 API.
+func NewBookingServiceV2Server(core *BookingServiceServer) *BookingServiceV2Server {
+	return &BookingServiceV2Server{core: core}
+}
+
+// fullMethodV2 returns the full gRPC method name of a v2 BookingService RPC.
+func fullMethodV2(name string) string {
+	return "/" + bookingv2.BookingService_ServiceDesc.ServiceName + "/" + name
+}
+
+func (s *BookingServiceV2Server) GetDeparture(ctx context.Context, req *bookingv2.GetDepartureRequest) (*bookingv2.Departure, error) {
+	if err := s.checkDeparture(req.Name); err != nil {
+		return nil, err
+	}
+	return s.departure(), nil
+}
+
+func (s *BookingServiceV2Server) ListDepartures(ctx context.Context, req *bookingv2.ListDeparturesRequest) (*bookingv2.ListDeparturesResponse, error) {
+	return &bookingv2.ListDeparturesResponse{Departures: []*bookingv2.Departure{s.departure()}}, nil
+}
+
+func (s *BookingServiceV2Server) ListSeats(ctx context.Context, req *bookingv2.ListSeatsRequest) (*bookingv2.ListSeatsResponse, error) {
+	if err := s.checkDeparture(req.Parent); err != nil {
+		return nil, err
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page size cannot be negative")
+	}
+
+	s.core.mu.RLock()
+	defer s.core.mu.RUnlock()
+
+	// Look every ticket up once rather than once per seat, and count the listed
+	// seats so that only those of the page need to be built.
+	perSection := s.core.layout.SeatsPerSection
+	occupied := make(map[pb.SeatSection]map[uint32]bool, len(seatSections))
+	count := len(seatSections) * int(perSection)
+	for _, section := range seatSections {
+		occupied[section] = make(map[uint32]bool)
+		for _, ticket := range s.core.SeatMapping[section.String()] {
+			if ticket.SeatNumber >= 1 && ticket.SeatNumber <= perSection && !occupied[section][ticket.SeatNumber] {
+				occupied[section][ticket.SeatNumber] = true
+				if req.AvailableOnly {
+					count--
+				}
+			}
+		}
+	}
+
+	query := &bookingv2.ListSeatsRequest{Parent: req.Parent, AvailableOnly: req.AvailableOnly}
+	start, end, next, err := s.core.page(req.PageToken, query, count, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	response := &bookingv2.ListSeatsResponse{NextPageToken: next}
+	index := 0
+	for _, section := range seatSections {
+		for number := uint32(1); number <= perSection && index < end; number++ {
+			available := !occupied[section][number]
+			if req.AvailableOnly && !available {
+				continue
+			}
+			if index >= start {
+				response.Seats = append(response.Seats, &bookingv2.Seat{
+					Name:      s.seatName(section, number),
+					Section:   section.String(),
+					Number:    number,
+					Available: available,
+				})
+			}
+			index++
+		}
+	}
+	return response, nil
+}
+
+func (s *BookingServiceV2Server) CreateTicket(ctx context.Context, req *bookingv2.CreateTicketRequest) (*bookingv2.Ticket, error) {
+	if req.Ticket == nil || req.Ticket.Passenger == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "a ticket with a passenger is required")
+	}
+	seatSection, seatNumber, err := s.parseSeat(req.Ticket.Seat)
+	if err != nil {
+		return nil, err
+	}
+	passenger := req.Ticket.Passenger
+	user := &pb.User{
+		FirstName: passenger.GivenName,
+		LastName:  passenger.FamilyName,
+		Email:     passenger.Email,
+		Phone:     passenger.Phone,
+		Locale:    passenger.Locale,
+	}
+
+	s.core.mu.Lock()
+	defer s.core.mu.Unlock()
+
+	ticket, err := s.core.purchase(ctx, fullMethodV2("CreateTicket"), user, seatSection, seatNumber, req.Ticket.Price)
+	if err != nil {
+		return nil, err
+	}
+	return s.ticket(ticket), nil
+}
+
+func (s *BookingServiceV2Server) GetTicket(ctx context.Context, req *bookingv2.GetTicketRequest) (*bookingv2.Ticket, error) {
+	s.core.mu.RLock()
+	defer s.core.mu.RUnlock()
+
+	userKey, err := s.ticketKey(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return s.ticket(s.core.Tickets[userKey]), nil
+}
+
+func (s *BookingServiceV2Server) ListTickets(ctx context.Context, req *bookingv2.ListTicketsRequest) (*bookingv2.ListTicketsResponse, error) {
+	filter := &pb.TicketFilter{EmailPrefix: req.EmailPrefix}
+	if req.Section != "" {
+		section, ok := pb.SeatSection_value[req.Section]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid seat section %q", req.Section)
+		}
+		filter.SeatSection = pb.SeatSection(section).Enum()
+	}
+	listed, err := s.core.ListTickets(ctx, &pb.ListTicketsRequest{
+		Filter:    filter,
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := &bookingv2.ListTicketsResponse{NextPageToken: listed.NextPageToken, TotalSize: listed.TotalSize}
+	for _, ticket := range listed.Tickets {
+		response.Tickets = append(response.Tickets, s.ticket(ticket))
+	}
+	return response, nil
+}
+
+func (s *BookingServiceV2Server) UpdateTicket(ctx context.Context, req *bookingv2.UpdateTicketRequest) (*bookingv2.Ticket, error) {
+	if req.Ticket == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "ticket is required")
+	}
+	for _, path := range req.UpdateMask.GetPaths() {
+		if path != "seat" {
+			return nil, status.Errorf(codes.InvalidArgument, "only the seat of a ticket can be updated, not %q", path)
+		}
+	}
+	seatSection, seatNumber, err := s.parseSeat(req.Ticket.Seat)
+	if err != nil {
+		return nil, err
+	}
+
+	s.core.mu.Lock()
+	defer s.core.mu.Unlock()
+
+	userKey, err := s.ticketKey(ctx, req.Ticket.Name)
+	if err != nil {
+		return nil, err
+	}
+	current := s.core.Tickets[userKey]
+	if current.SeatSection == seatSection && current.SeatNumber == seatNumber {
+		return s.ticket(current), nil
+	}
+	_, ticket, err := s.core.moveSeat(ctx, fullMethodV2("UpdateTicket"), userKey, seatSection, seatNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.ticket(ticket), nil
+}
+
+func (s *BookingServiceV2Server) DeleteTicket(ctx context.Context, req *bookingv2.DeleteTicketRequest) (*emptypb.Empty, error) {
+	s.core.mu.Lock()
+	defer s.core.mu.Unlock()
+
+	userKey, err := s.ticketKey(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	s.core.cancel(ctx, fullMethodV2("DeleteTicket"), userKey)
+	return &emptypb.Empty{}, nil
+}
+
+// departureName returns the name of the departure being booked, derived from
+// the stations of the layout, e.g. departures/london-france.
+func (s *BookingServiceV2Server) departureName() string {
+	return "departures/" + slug(s.core.layout.From) + "-" + slug(s.core.layout.To)
+}
+
+// slug lowercases text and joins its words with dashes.
+func slug(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// checkDeparture returns a NotFound error unless name is the departure being
+// booked.
+func (s *BookingServiceV2Server) checkDeparture(name string) error {
+	if name != s.departureName() {
+		return status.Errorf(codes.NotFound, "departure %q not found", name)
+	}
+	return nil
+}
+
+// departure describes the departure being booked.
+func (s *BookingServiceV2Server) departure() *bookingv2.Departure {
+	departure := &bookingv2.Departure{
+		Name:            s.departureName(),
+		Origin:          s.core.layout.From,
+		Destination:     s.core.layout.To,
+		SeatsPerSection: s.core.layout.SeatsPerSection,
+	}
+	for _, occupancy := range s.core.Occupancy() {
+		departure.Sections = append(departure.Sections, occupancy.Section.String())
+		departure.AvailableSeats += occupancy.Free
+	}
+	return departure
+}
+
+// seatName returns the name of a seat of the departure being booked.
+func (s *BookingServiceV2Server) seatName(seatSection pb.SeatSection, seatNumber uint32) string {
+	return s.departureName() + "/seats/" + seatSection.String() + strconv.FormatUint(uint64(seatNumber), 10)
+}
+
+// parseSeat returns the section and number of the seat named name, which must
+// belong to the departure being booked.
+func (s *BookingServiceV2Server) parseSeat(name string) (pb.SeatSection, uint32, error) {
+	if name == "" {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "seat is required")
+	}
+	departure, seat, ok := strings.Cut(name, "/seats/")
+	if !ok || seat == "" {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "invalid seat name %q, expected departures/{departure}/seats/{seat}", name)
+	}
+	if err := s.checkDeparture(departure); err != nil {
+		return 0, 0, err
+	}
+	section, ok := pb.SeatSection_value[seat[:1]]
+	number, err := strconv.ParseUint(seat[1:], 10, 32)
+	if !ok || err != nil {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "invalid seat %q, expected a section followed by a seat number, e.g. A7", seat)
+	}
+	if err := s.core.checkSeat(pb.SeatSection(section), uint32(number)); err != nil {
+		return 0, 0, err
+	}
+	return pb.SeatSection(section), uint32(number), nil
+}
+
+// ticketKey returns the key the ticket named name is stored under, checking
+// that the caller may access it. Callers must hold s.core.mu.
+func (s *BookingServiceV2Server) ticketKey(ctx context.Context, name string) (string, error) {
+	id, ok := strings.CutPrefix(name, "tickets/")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", status.Errorf(codes.InvalidArgument, "invalid ticket name %q, expected tickets/{ticket}", name)
+	}
+	userKey, exists := s.core.ticketIDs[id]
+	if !exists {
+		return "", status.Errorf(codes.NotFound, "ticket %q not found", name)
+	}
+	if err := s.core.authorizeUser(ctx, userKey); err != nil {
+		return "", err
+	}
+	return userKey, nil
+}
+
+// ticket converts a v1 ticket into its v2 representation.
+func (s *BookingServiceV2Server) ticket(ticket *pb.Ticket) *bookingv2.Ticket {
+	converted := &bookingv2.Ticket{
+		Name:      "tickets/" + ticket.Id,
+		Departure: s.departureName(),
+		Seat:      s.seatName(ticket.SeatSection, ticket.SeatNumber),
+		Passenger: &bookingv2.Passenger{
+			GivenName:  ticket.User.GetFirstName(),
+			FamilyName: ticket.User.GetLastName(),
+			Email:      ticket.User.GetEmail(),
+			Phone:      ticket.User.GetPhone(),
+			Locale:     ticket.User.GetLocale(),
+		},
+		Price:     ticket.PricePaid,
+		Signature: append([]byte(nil), ticket.Signature...),
+	}
+	if ticket.BoardedAt != nil {
+		converted.BoardTime = proto.Clone(ticket.BoardedAt).(*timestamppb.Timestamp)
+	}
+	return converted
+}
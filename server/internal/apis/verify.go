@@ -0,0 +1,40 @@
+package apis
+
+import (
+	"context"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// VerifyTicket checks that a ticket payload was issued by this server and is
+// still good for travel, returning the ticket as currently booked.
+func (s *BookingServiceServer) VerifyTicket(ctx context.Context, req *pb.VerifyTicketRequest) (*pb.VerifyTicketResponse, error) {
+	claims, err := s.signer.Verify(req.TicketPayload)
+	if err != nil {
+		return &pb.VerifyTicketResponse{Validity: pb.TicketValidity_TICKET_VALIDITY_FORGED}, nil
+	}
+
+	// Authorize against the passenger the ticket was signed for before telling
+	// anything about the booking, so that passengers cannot learn whether
+	// another passenger's ticket was cancelled.
+	owner, _ := s.emails.Normalize(claims.Email)
+	if err := s.authorizeUser(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// The signature proves the ticket was issued here, so a ticket that can no
+	// longer be found was cancelled.
+	userKey, exists := s.ticketIDs[claims.TicketID]
+	if !exists {
+		return &pb.VerifyTicketResponse{Validity: pb.TicketValidity_TICKET_VALIDITY_CANCELLED}, nil
+	}
+	ticket := proto.Clone(s.Tickets[userKey]).(*pb.Ticket)
+	if ticket.SeatSection.String() != claims.SeatSection || ticket.SeatNumber != claims.SeatNumber {
+		return &pb.VerifyTicketResponse{Validity: pb.TicketValidity_TICKET_VALIDITY_MOVED, Ticket: ticket}, nil
+	}
+	return &pb.VerifyTicketResponse{Validity: pb.TicketValidity_TICKET_VALIDITY_VALID, Ticket: ticket}, nil
+}
@@ -0,0 +1,185 @@
+package apis_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func listAuditEvents(t *testing.T, server *api.BookingServiceServer, req *pb.ListAuditEventsRequest) *pb.ListAuditEventsResponse {
+	t.Helper()
+	response, err := server.ListAuditEvents(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ListAuditEvents failed: %v", err)
+	}
+	return response
+}
+
+func TestAuditRecordsEveryMutation(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	agent := auth.NewContext(context.Background(), &auth.Identity{Subject: "agent-7", Roles: []string{auth.RoleAdmin}})
+	conductor := auth.NewContext(context.Background(), &auth.Identity{Subject: "conductor-2", Roles: []string{auth.RoleConductor}})
+
+	purchased, err := server.PurchaseTicket(agent, &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	})
+	if err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	ticketID := purchased.Ticket.Id
+	if _, err := server.ModifyUserSeat(agent, &pb.ModifyUserSeatRequest{Email: "john.doe@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 9}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	if _, err := server.CheckIn(conductor, &pb.CheckInRequest{TicketId: ticketID}); err != nil {
+		t.Fatalf("CheckIn failed: %v", err)
+	}
+	// Rejected changes are not changes.
+	if _, err := server.RemoveUser(agent, &pb.RemoveUserRequest{Email: "nobody@example.com"}); err == nil {
+		t.Fatalf("Expected removing an unknown user to fail")
+	}
+	if _, err := server.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "john.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+
+	response := listAuditEvents(t, server, &pb.ListAuditEventsRequest{})
+	if !response.ChainIntact || response.FirstBrokenSequence != 0 {
+		t.Errorf("Expected the chain to be intact, got %v", response)
+	}
+	expected := []struct {
+		method string
+		actor  string
+	}{
+		{"/BookingService.BookingService/PurchaseTicket", "agent-7"},
+		{"/BookingService.BookingService/ModifyUserSeat", "agent-7"},
+		{"/BookingService.BookingService/CheckIn", "conductor-2"},
+		{"/BookingService.BookingService/RemoveUser", "anonymous"},
+	}
+	if len(response.Events) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), response.Events)
+	}
+	for i, event := range response.Events {
+		if event.Method != expected[i].method || event.Actor != expected[i].actor || event.TicketId != ticketID {
+			t.Errorf("Event %d: expected %s by %s, got %v", i+1, expected[i].method, expected[i].actor, event)
+		}
+		if event.Sequence != uint64(i+1) || event.Time == nil {
+			t.Errorf("Event %d: expected a sequence and a time, got %v", i+1, event)
+		}
+	}
+
+	purchase, seatChange, checkIn, removal := response.Events[0], response.Events[1], response.Events[2], response.Events[3]
+	if purchase.Before != nil || purchase.After.GetSeatNumber() != 1 {
+		t.Errorf("Expected the purchase to record the new ticket only, got %v", purchase)
+	}
+	if seatChange.Before.GetSeatSection() != pb.SeatSection_A || seatChange.Before.GetSeatNumber() != 1 ||
+		seatChange.After.GetSeatSection() != pb.SeatSection_B || seatChange.After.GetSeatNumber() != 9 {
+		t.Errorf("Expected the seat change to record both seats, got %v", seatChange)
+	}
+	if checkIn.Before.GetBoardedAt() != nil || checkIn.After.GetBoardedAt() == nil {
+		t.Errorf("Expected the check-in to record the boarding, got %v", checkIn)
+	}
+	if removal.Before.GetSeatNumber() != 9 || removal.After != nil {
+		t.Errorf("Expected the removal to record the cancelled ticket only, got %v", removal)
+	}
+}
+
+func TestListAuditEventsFilterAndPaging(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		purchase(t, server, "User", "Number", email, pb.SeatSection_A, uint32(i+1), 20)
+		if _, err := server.ModifyUserSeat(context.Background(), &pb.ModifyUserSeatRequest{Email: email, NewSeatSection: pb.SeatSection_B, NewSeatNumber: uint32(i + 1)}); err != nil {
+			t.Fatalf("ModifyUserSeat failed: %v", err)
+		}
+	}
+
+	filter := &pb.AuditFilter{Method: "ModifyUserSeat"}
+	var sequences []uint64
+	pageToken := ""
+	for {
+		response := listAuditEvents(t, server, &pb.ListAuditEventsRequest{Filter: filter, PageSize: 2, PageToken: pageToken})
+		for _, event := range response.Events {
+			sequences = append(sequences, event.Sequence)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+	if len(sequences) != 3 || sequences[0] != 2 || sequences[1] != 4 || sequences[2] != 6 {
+		t.Errorf("Expected the seat changes 2, 4 and 6, got %v", sequences)
+	}
+
+	first := listAuditEvents(t, server, &pb.ListAuditEventsRequest{Filter: filter, PageSize: 1})
+	_, err := server.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{PageToken: first.NextPageToken})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a page token of another filter to be rejected, got %v", err)
+	}
+
+	ticketID := first.Events[0].TicketId
+	byTicket := listAuditEvents(t, server, &pb.ListAuditEventsRequest{Filter: &pb.AuditFilter{TicketId: ticketID}})
+	if len(byTicket.Events) != 2 {
+		t.Errorf("Expected the purchase and the seat change of %s, got %v", ticketID, byTicket.Events)
+	}
+	future := listAuditEvents(t, server, &pb.ListAuditEventsRequest{Filter: &pb.AuditFilter{Since: first.Events[0].Time, Until: first.Events[0].Time}})
+	if len(future.Events) != 0 {
+		t.Errorf("Expected an empty time range to match nothing, got %v", future.Events)
+	}
+}
+
+func TestAuditLogSurvivesRestartAndShowsTampering(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bookings.json")
+	server := api.NewBookingServiceServer(api.WithStore(storage.NewFile(path)))
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	if err := server.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	restarted := api.NewBookingServiceServer(api.WithStore(storage.NewFile(path)))
+	if err := restarted.Restore(ctx); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if _, err := restarted.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "john.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	response := listAuditEvents(t, restarted, &pb.ListAuditEventsRequest{})
+	if len(response.Events) != 2 || !response.ChainIntact {
+		t.Fatalf("Expected the restored log to continue intact, got %v", response)
+	}
+	if err := restarted.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Pretend the passenger was removed by someone else.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	tampered := bytes.Replace(raw, []byte(`"actor":"anonymous"`), []byte(`"actor":"passenger"`), 1)
+	tampered = bytes.Replace(tampered, []byte(`"actor": "anonymous"`), []byte(`"actor": "passenger"`), 1)
+	if bytes.Equal(raw, tampered) {
+		t.Fatalf("Found no actor to tamper with in %s", raw)
+	}
+	if err := os.WriteFile(path, tampered, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	reloaded := api.NewBookingServiceServer(api.WithStore(storage.NewFile(path)))
+	if err := reloaded.Restore(ctx); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	response = listAuditEvents(t, reloaded, &pb.ListAuditEventsRequest{})
+	if response.ChainIntact || response.FirstBrokenSequence != 1 {
+		t.Errorf("Expected the edited event to break the chain, got intact=%v at %d", response.ChainIntact, response.FirstBrokenSequence)
+	}
+}
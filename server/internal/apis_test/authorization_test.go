@@ -0,0 +1,228 @@
+package apis_test
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const passengerEmail = "pat@example.com"
+
+// rpcCalls issues one minimal call of every BookingService RPC. Requests made
+// on behalf of a passenger use passengerEmail so ownership checks pass.
+var rpcCalls = map[string]func(ctx context.Context, client pb.BookingServiceClient) error{
+	"PurchaseTicket": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{User: &pb.User{FirstName: "Pat", LastName: "Doe", Email: passengerEmail}, SeatSection: pb.SeatSection_B, SeatNumber: 7})
+		return err
+	},
+	"GetReceipt": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.GetReceipt(ctx, &pb.GetReceiptRequest{Email: passengerEmail})
+		return err
+	},
+	"RenderReceipt": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.RenderReceipt(ctx, &pb.RenderReceiptRequest{Email: passengerEmail})
+		return err
+	},
+	"VerifyTicket": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.VerifyTicket(ctx, &pb.VerifyTicketRequest{TicketPayload: "BMS1.forged"})
+		return err
+	},
+	"ListTickets": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.ListTickets(ctx, &pb.ListTicketsRequest{})
+		return err
+	},
+	"SearchPassengers": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.SearchPassengers(ctx, &pb.SearchPassengersRequest{Query: "pat"})
+		return err
+	},
+	"ImportBookings": func(ctx context.Context, client pb.BookingServiceClient) error {
+		stream, err := client.ImportBookings(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = stream.CloseAndRecv()
+		return err
+	},
+	"ExportManifest": func(ctx context.Context, client pb.BookingServiceClient) error {
+		stream, err := client.ExportManifest(ctx, &pb.ExportManifestRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	},
+	"CheckIn": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.CheckIn(ctx, &pb.CheckInRequest{TicketId: "unknown"})
+		return err
+	},
+	"ScanTicket": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.ScanTicket(ctx, &pb.ScanTicketRequest{TicketPayload: "BMS1.forged"})
+		return err
+	},
+	"GetBoardingReport": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.GetBoardingReport(ctx, &pb.GetBoardingReportRequest{})
+		return err
+	},
+	"GetUsersAndSeatAllocated": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.GetUsersAndSeatAllocated(ctx, &pb.GetUsersAndSeatAllocatedRequest{SeatSection: pb.SeatSection_A})
+		return err
+	},
+	"RemoveUser": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "nobody@example.com"})
+		return err
+	},
+	"ModifyUserSeat": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "nobody@example.com", NewSeatSection: pb.SeatSection_A, NewSeatNumber: 1})
+		return err
+	},
+	"ListAuditEvents": func(ctx context.Context, client pb.BookingServiceClient) error {
+		_, err := client.ListAuditEvents(ctx, &pb.ListAuditEventsRequest{})
+		return err
+	},
+}
+
+// allowedRoles is the expected policy, written out independently of api.Policy.
+var allowedRoles = map[string][]string{
+	"PurchaseTicket":           {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	"GetReceipt":               {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	"RenderReceipt":            {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	"VerifyTicket":             {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"ListTickets":              {auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"SearchPassengers":         {auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"ImportBookings":           {auth.RoleAgent, auth.RoleAdmin},
+	"ExportManifest":           {auth.RoleConductor, auth.RoleAdmin},
+	"CheckIn":                  {auth.RoleConductor, auth.RoleAdmin},
+	"ScanTicket":               {auth.RoleConductor, auth.RoleAdmin},
+	"GetBoardingReport":        {auth.RoleConductor, auth.RoleAdmin},
+	"GetUsersAndSeatAllocated": {auth.RoleAdmin},
+	"RemoveUser":               {auth.RoleAdmin},
+	"ModifyUserSeat":           {auth.RoleAdmin},
+	"ListAuditEvents":          {auth.RoleAdmin},
+}
+
+var allRoles = []string{auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin, "none"}
+
+func TestPolicyCoversEveryRPC(t *testing.T) {
+	methods := 0
+	for _, service := range []struct {
+		desc  grpc.ServiceDesc
+		calls map[string]bool
+	}{
+		{pb.BookingService_ServiceDesc, callNames(rpcCalls)},
+		{bookingv2.BookingService_ServiceDesc, callNames(rpcCallsV2)},
+	} {
+		var names []string
+		for _, method := range service.desc.Methods {
+			names = append(names, method.MethodName)
+		}
+		for _, stream := range service.desc.Streams {
+			names = append(names, stream.StreamName)
+		}
+		for _, method := range names {
+			if _, ok := api.Policy["/"+service.desc.ServiceName+"/"+method]; !ok {
+				t.Errorf("Policy has no entry for %s of %s", method, service.desc.ServiceName)
+			}
+			if !service.calls[method] {
+				t.Errorf("No test call for %s of %s", method, service.desc.ServiceName)
+			}
+		}
+		methods += len(names)
+	}
+	if len(api.Policy) != methods {
+		t.Errorf("Policy lists %d methods, the services have %d", len(api.Policy), methods)
+	}
+}
+
+// callNames returns the names of the RPCs calls has a call for.
+func callNames[C any](calls map[string]C) map[string]bool {
+	names := make(map[string]bool, len(calls))
+	for name := range calls {
+		names[name] = true
+	}
+	return names
+}
+
+func TestAuthorizationMatrix(t *testing.T) {
+	var keys []auth.APIKey
+	for _, role := range allRoles {
+		key := auth.APIKey{Name: role, KeySHA256: auth.HashAPIKey("key-" + role), Email: passengerEmail}
+		if role != "none" {
+			key.Roles = []string{role}
+		}
+		keys = append(keys, key)
+	}
+	authenticator := auth.NewAuthenticator(auth.WithAPIKeys(keys), auth.WithPolicy(api.Policy))
+
+	for method, call := range rpcCalls {
+		for _, role := range allRoles {
+			allowed := false
+			for _, allowedRole := range allowedRoles[method] {
+				allowed = allowed || allowedRole == role
+			}
+			t.Run(method+"/"+role, func(t *testing.T) {
+				client := dialServer(t, api.NewBookingServiceServer(),
+					grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()),
+					grpc.ChainStreamInterceptor(authenticator.StreamInterceptor()))
+				ctx := metadata.AppendToOutgoingContext(context.Background(), auth.APIKeyHeader, "key-"+role)
+				err := call(ctx, client)
+				denied := status.Code(err) == codes.PermissionDenied
+				if allowed && denied {
+					t.Errorf("Expected %s to be allowed to call %s, got %v", role, method, err)
+				}
+				if !allowed && !denied {
+					t.Errorf("Expected PermissionDenied for %s calling %s, got %v", role, method, err)
+				}
+			})
+		}
+	}
+}
+
+func TestPassengersOnlyAccessTheirOwnTickets(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "Pat", "Doe", passengerEmail, pb.SeatSection_A, 1, 20)
+	purchase(t, server, "Sam", "Roe", "sam@example.com", pb.SeatSection_A, 2, 20)
+
+	passenger := auth.NewContext(context.Background(), &auth.Identity{Subject: "pat", Email: "Pat@Example.com", Roles: []string{auth.RolePassenger}})
+	agent := auth.NewContext(context.Background(), &auth.Identity{Subject: "desk", Roles: []string{auth.RoleAgent}})
+
+	if _, err := server.GetReceipt(passenger, &pb.GetReceiptRequest{Email: passengerEmail}); err != nil {
+		t.Errorf("Expected a passenger to read their own receipt, got %v", err)
+	}
+	if _, err := server.GetReceipt(passenger, &pb.GetReceiptRequest{Email: "sam@example.com"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied reading another passenger's receipt, got %v", err)
+	}
+	if _, err := server.RenderReceipt(passenger, &pb.RenderReceiptRequest{Email: "sam@example.com"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied rendering another passenger's receipt, got %v", err)
+	}
+	if _, err := server.PurchaseTicket(passenger, &pb.PurchaseTicketRequest{User: &pb.User{FirstName: "Kim", Email: "kim@example.com"}, SeatSection: pb.SeatSection_B, SeatNumber: 3}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied booking for someone else, got %v", err)
+	}
+	if _, err := server.VerifyTicket(passenger, &pb.VerifyTicketRequest{TicketPayload: signing.Payload(ticketOf(t, server, "sam@example.com"))}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied verifying another passenger's ticket, got %v", err)
+	}
+
+	if _, err := server.GetReceipt(agent, &pb.GetReceiptRequest{Email: "sam@example.com"}); err != nil {
+		t.Errorf("Expected an agent to read any receipt, got %v", err)
+	}
+	noEmail := auth.NewContext(context.Background(), &auth.Identity{Subject: "anon", Roles: []string{auth.RolePassenger}})
+	if _, err := server.GetReceipt(noEmail, &pb.GetReceiptRequest{Email: passengerEmail}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a passenger without an email, got %v", err)
+	}
+
+	cancelled := signing.Payload(ticketOf(t, server, "sam@example.com"))
+	if _, err := server.RemoveUser(agent, &pb.RemoveUserRequest{Email: "sam@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if _, err := server.VerifyTicket(passenger, &pb.VerifyTicketRequest{TicketPayload: cancelled}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied verifying another passenger's cancelled ticket, got %v", err)
+	}
+}
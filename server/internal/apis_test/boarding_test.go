@@ -0,0 +1,103 @@
+package apis_test
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func ticketOf(t *testing.T, server *api.BookingServiceServer, email string) *pb.Ticket {
+	t.Helper()
+	response, err := server.GetReceipt(context.Background(), &pb.GetReceiptRequest{Email: email})
+	if err != nil {
+		t.Fatalf("GetReceipt failed: %v", err)
+	}
+	return response.Ticket
+}
+
+func TestCheckIn(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	ticket := ticketOf(t, server, "john.doe@example.com")
+	ctx := context.Background()
+
+	response, err := server.CheckIn(ctx, &pb.CheckInRequest{TicketId: ticket.Id})
+	if err != nil {
+		t.Fatalf("CheckIn failed: %v", err)
+	}
+	if response.Ticket.BoardedAt == nil {
+		t.Fatalf("Boarding time not recorded")
+	}
+
+	_, err = server.CheckIn(ctx, &pb.CheckInRequest{TicketId: ticket.Id})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("Expected AlreadyExists for a duplicate check-in, got %v", err)
+	}
+	_, err = server.CheckIn(ctx, &pb.CheckInRequest{TicketId: "unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound for an unknown ticket, got %v", err)
+	}
+}
+
+func TestScanTicket(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	purchase(t, server, "Jane", "Doe", "jane.doe@example.com", pb.SeatSection_A, 2, 20)
+	ctx := context.Background()
+
+	// John moved after his e-ticket was printed
+	johnPayload := signing.Payload(ticketOf(t, server, "john.doe@example.com"))
+	if _, err := server.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "john.doe@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 9}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	response, err := server.ScanTicket(ctx, &pb.ScanTicketRequest{TicketPayload: johnPayload})
+	if err != nil {
+		t.Fatalf("ScanTicket failed: %v", err)
+	}
+	if response.Validity != pb.TicketValidity_TICKET_VALIDITY_MOVED || response.Ticket.SeatNumber != 9 || response.Ticket.BoardedAt == nil {
+		t.Fatalf("Unexpected scan result: %v", response)
+	}
+
+	// Jane cancelled her booking
+	janePayload := signing.Payload(ticketOf(t, server, "jane.doe@example.com"))
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "jane.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	_, err = server.ScanTicket(ctx, &pb.ScanTicketRequest{TicketPayload: janePayload})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition for a cancelled ticket, got %v", err)
+	}
+
+	_, err = server.ScanTicket(ctx, &pb.ScanTicketRequest{TicketPayload: "BMS1.forged.payload"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for a forged payload, got %v", err)
+	}
+}
+
+func TestBoardingReport(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_B, 1, 20)
+	purchase(t, server, "Jane", "Doe", "jane.doe@example.com", pb.SeatSection_A, 5, 20)
+	purchase(t, server, "Alice", "Smith", "alice@example.com", pb.SeatSection_A, 2, 20)
+	ctx := context.Background()
+
+	if _, err := server.CheckIn(ctx, &pb.CheckInRequest{TicketId: ticketOf(t, server, "jane.doe@example.com").Id}); err != nil {
+		t.Fatalf("CheckIn failed: %v", err)
+	}
+
+	report, err := server.GetBoardingReport(ctx, &pb.GetBoardingReportRequest{Departure: &pb.Departure{From: "London", To: "France"}})
+	if err != nil {
+		t.Fatalf("GetBoardingReport failed: %v", err)
+	}
+	if report.Booked != 3 || report.Boarded != 1 || len(report.NoShows) != 2 {
+		t.Fatalf("Unexpected report: %v", report)
+	}
+	if report.NoShows[0].User.Email != "alice@example.com" || report.NoShows[1].User.Email != "john.doe@example.com" {
+		t.Fatalf("No-shows not ordered by seat: %v", report.NoShows)
+	}
+}
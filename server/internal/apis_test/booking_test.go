@@ -3,7 +3,15 @@ package apis_test
 import (
 	"context"
 	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
 	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"path/filepath"
 	"testing"
 )
 
@@ -67,7 +75,7 @@ func TestGetReceipt(t *testing.T) {
 		SeatNumber:  1,
 	}
 
-	server.Tickets[email] = *expectedTicket
+	server.Tickets[email] = expectedTicket
 
 	ctx := context.Background()
 	request := &pb.GetReceiptRequest{Email: email}
@@ -101,7 +109,7 @@ func TestGetUsersAndSeatAllocated(t *testing.T) {
 		SeatNumber:  1,
 	}
 
-	server.SeatMapping[pb.SeatSection_A.String()] = map[string]pb.Ticket{userEmail: *expectedTicket}
+	server.SeatMapping[pb.SeatSection_A.String()] = map[string]*pb.Ticket{userEmail: expectedTicket}
 
 	ctx := context.Background()
 	request := &pb.GetUsersAndSeatAllocatedRequest{SeatSection: pb.SeatSection_A}
@@ -140,8 +148,8 @@ func TestRemoveUser(t *testing.T) {
 		SeatNumber:  1,
 	}
 
-	server.Tickets[userEmail] = *expectedTicket
-	server.SeatMapping[pb.SeatSection_A.String()] = map[string]pb.Ticket{userEmail: *expectedTicket}
+	server.Tickets[userEmail] = expectedTicket
+	server.SeatMapping[pb.SeatSection_A.String()] = map[string]*pb.Ticket{userEmail: expectedTicket}
 
 	ctx := context.Background()
 	request := &pb.RemoveUserRequest{Email: userEmail}
@@ -180,8 +188,8 @@ func TestModifyUserSeat(t *testing.T) {
 		SeatNumber:  1,
 	}
 
-	server.Tickets[userEmail] = *expectedTicket
-	server.SeatMapping[pb.SeatSection_A.String()] = map[string]pb.Ticket{userEmail: *expectedTicket}
+	server.Tickets[userEmail] = expectedTicket
+	server.SeatMapping[pb.SeatSection_A.String()] = map[string]*pb.Ticket{userEmail: expectedTicket}
 
 	ctx := context.Background()
 	request := &pb.ModifyUserSeatRequest{
@@ -210,3 +218,84 @@ func TestModifyUserSeat(t *testing.T) {
 		t.Fatalf("Unexpected response. Expected '%s', got '%s'", expectedMessage, response.Msg)
 	}
 }
+
+func TestGetReceiptUnknownUser(t *testing.T) {
+	server := api.NewBookingServiceServer()
+
+	_, err := server.GetReceipt(context.Background(), &pb.GetReceiptRequest{Email: "nobody@example.com"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound for an email without a ticket, got %v", err)
+	}
+}
+
+func TestTicketsHeldPerCaller(t *testing.T) {
+	store := storage.NewFile(filepath.Join(t.TempDir(), "bookings.json"))
+	limits := api.Limits{MaxImportRows: 10, MaxPageSize: 10, MaxTicketsPerCaller: 2}
+	server := api.NewBookingServiceServer(api.WithLimits(limits), api.WithStore(store))
+	script := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 41000}})
+	other := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.8"), Port: 41000}})
+	agent := auth.NewContext(script, &auth.Identity{Subject: "desk", Roles: []string{auth.RoleAgent}})
+	buy := func(ctx context.Context, email string, seat uint32) error {
+		_, err := server.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{User: &pb.User{Email: email}, SeatSection: pb.SeatSection_A, SeatNumber: seat})
+		return err
+	}
+
+	if err := buy(script, "one@example.com", 1); err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	if _, err := api.NewBookingServiceV2Server(server).CreateTicket(script, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+		Seat:      "departures/london-france/seats/A2",
+		Passenger: &bookingv2.Passenger{Email: "two@example.com"},
+	}}); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	if err := buy(script, "three@example.com", 3); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted for a third ticket, whichever version bought the others, got %v", err)
+	}
+	if err := buy(other, "three@example.com", 3); err != nil {
+		t.Errorf("Expected another caller to have a cap of their own, got %v", err)
+	}
+	if err := buy(agent, "four@example.com", 4); err != nil {
+		t.Errorf("Expected staff to be exempt, got %v", err)
+	}
+
+	// The cap survives restarts, and cancelling a ticket frees a place under it.
+	if err := server.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	server = api.NewBookingServiceServer(api.WithLimits(limits), api.WithStore(store))
+	if err := server.Restore(context.Background()); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := buy(script, "five@example.com", 5); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected the cap to be restored, got %v", err)
+	}
+	if _, err := server.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "one@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if err := buy(script, "five@example.com", 5); err != nil {
+		t.Errorf("Expected a cancellation to free a place, got %v", err)
+	}
+}
+
+func TestResponsesDoNotShareStoredTickets(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	ctx := context.Background()
+	purchased, err := server.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{User: &pb.User{Email: "pat@example.com"}, SeatSection: pb.SeatSection_A, SeatNumber: 1})
+	if err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	receipt, err := server.GetReceipt(ctx, &pb.GetReceiptRequest{Email: "pat@example.com"})
+	if err != nil {
+		t.Fatalf("GetReceipt failed: %v", err)
+	}
+
+	if _, err := server.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "pat@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 2}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	for name, ticket := range map[string]*pb.Ticket{"PurchaseTicket": purchased.Ticket, "GetReceipt": receipt.Ticket} {
+		if ticket.SeatSection != pb.SeatSection_A || ticket.SeatNumber != 1 {
+			t.Errorf("Expected the ticket returned by %s to keep its seat, got %s%d", name, ticket.SeatSection, ticket.SeatNumber)
+		}
+	}
+}
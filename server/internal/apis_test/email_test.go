@@ -0,0 +1,76 @@
+package apis_test
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/helpers"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEmailIsCaseInsensitiveUserKey(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	ctx := context.Background()
+	purchase(t, server, "John", "Doe", "John.Doe@Example.COM", pb.SeatSection_A, 1, 20)
+
+	_, err := server.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: " john.doe@example.com "},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  2,
+	})
+	if err == nil {
+		t.Fatalf("Expected a second booking for the same normalized email to fail")
+	}
+
+	response, err := server.GetReceipt(ctx, &pb.GetReceiptRequest{Email: "JOHN.DOE@example.com"})
+	if err != nil {
+		t.Fatalf("GetReceipt failed: %v", err)
+	}
+	if response.Ticket.SeatNumber != 1 || response.Ticket.User.Email != "John.Doe@Example.COM" {
+		t.Fatalf("Unexpected receipt: %v", response.Ticket)
+	}
+
+	if _, err := server.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "john.doe@EXAMPLE.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 3}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "  John.Doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if len(server.Tickets) != 0 || len(server.SeatMapping[pb.SeatSection_B.String()]) != 0 {
+		t.Fatalf("User not removed")
+	}
+}
+
+func TestEmailProviderRules(t *testing.T) {
+	server := api.NewBookingServiceServer(api.WithEmailNormalizer(helpers.EmailNormalizer{ProviderRules: true}))
+	purchase(t, server, "Jane", "Doe", "jane.doe+trains@gmail.com", pb.SeatSection_A, 1, 20)
+
+	_, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "Jane", LastName: "Doe", Email: "JaneDoe@googlemail.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  2,
+	})
+	if err == nil {
+		t.Fatalf("Expected the googlemail alias of a gmail address to be treated as the same user")
+	}
+	if _, exists := server.Tickets["janedoe@gmail.com"]; !exists {
+		t.Fatalf("Ticket not stored under the canonical email")
+	}
+}
+
+func TestInvalidEmailRejected(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	for _, email := range []string{"", "no-at-sign", "@example.com", "john@", "a@b@c"} {
+		_, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+			User:        &pb.User{FirstName: "John", LastName: "Doe", Email: email},
+			SeatSection: pb.SeatSection_A,
+			SeatNumber:  1,
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("Expected InvalidArgument for %q, got %v", email, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package apis_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+func TestChangesArePublished(t *testing.T) {
+	ctx := context.Background()
+	outbox := events.NewOutbox([]events.Webhook{{Name: "crm", URL: "http://127.0.0.1:1", Secret: "secret"}})
+	server := api.NewBookingServiceServer(api.WithOutbox(outbox))
+
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	ticketID := ticketOf(t, server, "john.doe@example.com").Id
+	if _, err := server.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "john.doe@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 9}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	// Rejected changes publish nothing.
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "nobody@example.com"}); err == nil {
+		t.Fatalf("Expected removing an unknown user to fail")
+	}
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "john.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+
+	pending, _ := outbox.Snapshot()
+	expected := []events.Type{events.TicketPurchased, events.SeatChanged, events.TicketCancelled}
+	if len(pending) != len(expected) {
+		t.Fatalf("Expected %d deliveries, got %d", len(expected), len(pending))
+	}
+	for i, delivery := range pending {
+		event := delivery.Event
+		if event.Type != expected[i] || event.TicketID != ticketID {
+			t.Errorf("Delivery %d: expected %s of %s, got %s of %s", i, expected[i], ticketID, event.Type, event.TicketID)
+		}
+		var change struct {
+			Before *struct{ SeatNumber int } `json:"before"`
+			After  *struct{ SeatNumber int } `json:"after"`
+		}
+		if err := json.Unmarshal(event.Data, &change); err != nil {
+			t.Fatalf("Delivery %d: invalid data %s: %v", i, event.Data, err)
+		}
+		switch event.Type {
+		case events.TicketPurchased:
+			if change.Before != nil || change.After == nil || change.After.SeatNumber != 1 {
+				t.Errorf("Unexpected purchase data %s", event.Data)
+			}
+		case events.SeatChanged:
+			if change.Before == nil || change.Before.SeatNumber != 1 || change.After == nil || change.After.SeatNumber != 9 {
+				t.Errorf("Unexpected seat change data %s", event.Data)
+			}
+		case events.TicketCancelled:
+			if change.Before == nil || change.Before.SeatNumber != 9 || change.After != nil {
+				t.Errorf("Unexpected cancellation data %s", event.Data)
+			}
+		}
+	}
+}
+
+func TestOutboxIsSavedWithTheBookings(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewFile(filepath.Join(t.TempDir(), "bookings.json"))
+	webhooks := []events.Webhook{{Name: "crm", URL: "http://127.0.0.1:1", Secret: "secret"}}
+	server := api.NewBookingServiceServer(api.WithStore(store), api.WithOutbox(events.NewOutbox(webhooks)))
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	if err := server.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// The restarted server delivers the event its predecessor could not.
+	received := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(events.EventTypeHeader)
+	}))
+	defer receiver.Close()
+	webhooks[0].URL = receiver.URL
+	outbox := events.NewOutbox(webhooks)
+	restarted := api.NewBookingServiceServer(api.WithStore(store), api.WithOutbox(outbox))
+	if err := restarted.Restore(ctx); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if pending, _ := outbox.Snapshot(); len(pending) != 1 {
+		t.Fatalf("Expected the pending delivery to be restored, got %+v", pending)
+	}
+	events.NewDispatcher(outbox).DeliverDue(ctx)
+	if eventType := <-received; eventType != string(events.TicketPurchased) {
+		t.Errorf("Expected a %s event, got %q", events.TicketPurchased, eventType)
+	}
+
+	// Delivering changes no booking but still has to be saved.
+	if err := restarted.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	snapshot, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(snapshot.Outbox) != 0 || len(snapshot.DeadLetters) != 0 || len(snapshot.Tickets) != 1 {
+		t.Errorf("Expected an empty outbox to be saved, got %+v", snapshot)
+	}
+}
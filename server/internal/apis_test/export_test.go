@@ -0,0 +1,95 @@
+package apis_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+func exportManifest(t *testing.T, client pb.BookingServiceClient, req *pb.ExportManifestRequest) (string, []byte) {
+	t.Helper()
+	stream, err := client.ExportManifest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ExportManifest failed: %v", err)
+	}
+	var contentType string
+	var document bytes.Buffer
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return contentType, document.Bytes()
+		}
+		if err != nil {
+			t.Fatalf("ExportManifest failed: %v", err)
+		}
+		if response.ContentType != "" {
+			contentType = response.ContentType
+		}
+		document.Write(response.Chunk)
+	}
+}
+
+func TestExportManifestCSV(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "Bob", "Jones", "bob@example.com", pb.SeatSection_B, 1, 20)
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 9, 20)
+	purchase(t, server, "Alice", "Smith", "alice@example.com", pb.SeatSection_A, 3, 20)
+	client := dialServer(t, server)
+
+	contentType, document := exportManifest(t, client, &pb.ExportManifestRequest{Format: pb.ManifestFormat_MANIFEST_FORMAT_CSV})
+	if contentType != "text/csv" {
+		t.Fatalf("Unexpected content type %q", contentType)
+	}
+	records, err := csv.NewReader(bytes.NewReader(document)).ReadAll()
+	if err != nil {
+		t.Fatalf("Manifest is not valid CSV: %v", err)
+	}
+	var seats []string
+	for _, record := range records[1:] {
+		seats = append(seats, record[0]+record[1])
+	}
+	if fmt.Sprint(seats) != "[A3 A9 B1]" {
+		t.Fatalf("Unexpected manifest order %v", seats)
+	}
+}
+
+func TestExportManifestJSONFiltersDeparture(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	client := dialServer(t, server)
+
+	_, document := exportManifest(t, client, &pb.ExportManifestRequest{
+		Departure: &pb.Departure{From: "Paris"},
+		Format:    pb.ManifestFormat_MANIFEST_FORMAT_JSON,
+	})
+	var manifest struct {
+		From       string            `json:"from"`
+		Passengers []json.RawMessage `json:"passengers"`
+	}
+	if err := json.Unmarshal(document, &manifest); err != nil {
+		t.Fatalf("Manifest is not valid JSON: %v", err)
+	}
+	if manifest.From != "Paris" || len(manifest.Passengers) != 0 {
+		t.Fatalf("Unexpected manifest %s", document)
+	}
+}
+
+func TestExportManifestPDF(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	for i := 1; i <= 50; i++ {
+		purchase(t, server, "Passenger", fmt.Sprintf("Number%d", i), fmt.Sprintf("passenger%d@example.com", i), pb.SeatSection_A, uint32(i), 20)
+	}
+	client := dialServer(t, server)
+
+	contentType, document := exportManifest(t, client, &pb.ExportManifestRequest{Format: pb.ManifestFormat_MANIFEST_FORMAT_PDF})
+	if contentType != "application/pdf" || !bytes.HasPrefix(document, []byte("%PDF-")) || !bytes.Contains(document, []byte("%%EOF")) {
+		t.Fatalf("Manifest is not a PDF document")
+	}
+}
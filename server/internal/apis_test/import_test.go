@@ -0,0 +1,156 @@
+package apis_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialServer serves server over an in-memory listener and returns a client connected to it.
+func dialServer(t *testing.T, server *api.BookingServiceServer, opts ...grpc.ServerOption) pb.BookingServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterBookingServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBookingServiceClient(conn)
+}
+
+func importRows(t *testing.T, client pb.BookingServiceClient, dryRun bool, rows ...*pb.ImportRow) *pb.ImportBookingsResponse {
+	t.Helper()
+	stream, err := client.ImportBookings(context.Background())
+	if err != nil {
+		t.Fatalf("ImportBookings failed: %v", err)
+	}
+	if err := stream.Send(&pb.ImportBookingsRequest{Payload: &pb.ImportBookingsRequest_Options{Options: &pb.ImportOptions{DryRun: dryRun}}}); err != nil {
+		t.Fatalf("Sending import options failed: %v", err)
+	}
+	for _, row := range rows {
+		if err := stream.Send(&pb.ImportBookingsRequest{Payload: &pb.ImportBookingsRequest_Row{Row: row}}); err != nil {
+			t.Fatalf("Sending import row failed: %v", err)
+		}
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("ImportBookings failed: %v", err)
+	}
+	return response
+}
+
+func importRow(number uint32, email string, section pb.SeatSection, seat uint32) *pb.ImportRow {
+	return &pb.ImportRow{
+		RowNumber:   number,
+		User:        &pb.User{FirstName: "Passenger", LastName: "Imported", Email: email},
+		SeatSection: section,
+		SeatNumber:  seat,
+		TicketPrice: 20,
+	}
+}
+
+func TestImportBookings(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	client := dialServer(t, server)
+
+	response := importRows(t, client, false,
+		importRow(2, "one@example.com", pb.SeatSection_A, 1),
+		importRow(3, "two@example.com", pb.SeatSection_B, 1),
+	)
+	if !response.Committed || len(response.Errors) != 0 || len(response.Tickets) != 2 {
+		t.Fatalf("Unexpected import response: %v", response)
+	}
+	if len(server.Tickets) != 2 || len(server.SeatMapping[pb.SeatSection_B.String()]) != 1 {
+		t.Fatalf("Imported bookings not stored")
+	}
+}
+
+func TestImportBookingsDryRun(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	client := dialServer(t, server)
+
+	response := importRows(t, client, true, importRow(1, "one@example.com", pb.SeatSection_A, 1))
+	if response.Committed || !response.DryRun || len(response.Tickets) != 1 {
+		t.Fatalf("Unexpected import response: %v", response)
+	}
+	if len(server.Tickets) != 0 {
+		t.Fatalf("Dry run stored bookings")
+	}
+	if preview := response.Tickets[0]; preview.Id != "" || preview.User.Id != 0 {
+		t.Errorf("Expected the preview to carry no IDs, got ticket %q and user %d", preview.Id, preview.User.Id)
+	}
+}
+
+func TestImportBookingsRejectsWholeBatchOnInvalidRow(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 5, 20)
+	client := dialServer(t, server)
+
+	response := importRows(t, client, false,
+		importRow(1, "one@example.com", pb.SeatSection_A, 1),
+		importRow(2, "two@example.com", pb.SeatSection_A, 1),
+		importRow(3, "JOHN.DOE@example.com", pb.SeatSection_B, 2),
+		importRow(4, "three@example.com", pb.SeatSection_A, 5),
+		importRow(5, "four@example.com", pb.SeatSection_A, 51),
+		importRow(6, "not-an-email", pb.SeatSection_A, 6),
+	)
+	if response.Committed {
+		t.Fatalf("Import with invalid rows was committed")
+	}
+	var failedRows []uint32
+	for _, rowError := range response.Errors {
+		failedRows = append(failedRows, rowError.RowNumber)
+	}
+	expected := []uint32{2, 3, 4, 5, 6}
+	if len(failedRows) != len(expected) {
+		t.Fatalf("Expected errors for rows %v, got %v", expected, response.Errors)
+	}
+	for i := range expected {
+		if failedRows[i] != expected[i] {
+			t.Fatalf("Expected errors for rows %v, got %v", expected, response.Errors)
+		}
+	}
+	if len(server.Tickets) != 1 || len(server.SeatMapping[pb.SeatSection_A.String()]) != 1 {
+		t.Fatalf("Failed import modified the bookings")
+	}
+}
+
+func TestImportBookingsCountsAgainstTicketsHeld(t *testing.T) {
+	server := api.NewBookingServiceServer(api.WithLimits(api.Limits{MaxImportRows: 10, MaxPageSize: 10, MaxTicketsPerCaller: 2}))
+	client := dialServer(t, server)
+
+	response := importRows(t, client, false,
+		importRow(1, "one@example.com", pb.SeatSection_A, 1),
+		importRow(2, "two@example.com", pb.SeatSection_A, 2),
+		importRow(3, "three@example.com", pb.SeatSection_A, 3),
+	)
+	if response.Committed || len(response.Errors) != 1 || response.Errors[0].RowNumber != 3 {
+		t.Fatalf("Expected the row over the cap to fail the import, got %v", response)
+	}
+
+	response = importRows(t, client, false,
+		importRow(1, "one@example.com", pb.SeatSection_A, 1),
+		importRow(2, "two@example.com", pb.SeatSection_A, 2),
+	)
+	if !response.Committed {
+		t.Fatalf("Unexpected import response: %v", response)
+	}
+	if _, err := client.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{User: &pb.User{Email: "three@example.com"}, SeatSection: pb.SeatSection_A, SeatNumber: 3}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected imported tickets to count against the cap, got %v", err)
+	}
+}
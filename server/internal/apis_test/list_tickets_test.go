@@ -0,0 +1,119 @@
+package apis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func purchase(t *testing.T, server *api.BookingServiceServer, firstName, lastName, email string, section pb.SeatSection, seat uint32, price float32) {
+	t.Helper()
+	_, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: firstName, LastName: lastName, Email: email},
+		SeatSection: section,
+		SeatNumber:  seat,
+		TicketPrice: price,
+	})
+	if err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+}
+
+func TestListTicketsPagination(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	for i := 1; i <= 5; i++ {
+		purchase(t, server, "User", fmt.Sprintf("Number%d", i), fmt.Sprintf("user%d@example.com", i), pb.SeatSection_B, uint32(6-i), 20)
+	}
+
+	ctx := context.Background()
+	var seats []uint32
+	pageToken := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("Too many pages returned")
+		}
+		response, err := server.ListTickets(ctx, &pb.ListTicketsRequest{PageSize: 2, PageToken: pageToken})
+		if err != nil {
+			t.Fatalf("ListTickets failed: %v", err)
+		}
+		if response.TotalSize != 5 {
+			t.Fatalf("Unexpected total size. Expected 5, got %d", response.TotalSize)
+		}
+		for _, ticket := range response.Tickets {
+			seats = append(seats, ticket.SeatNumber)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	expected := []uint32{1, 2, 3, 4, 5}
+	if fmt.Sprint(seats) != fmt.Sprint(expected) {
+		t.Fatalf("Unexpected seat order. Expected %v, got %v", expected, seats)
+	}
+}
+
+func TestListTicketsFilterAndSort(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	purchase(t, server, "Jane", "Doe", "jane.doe@example.com", pb.SeatSection_A, 7, 35)
+	purchase(t, server, "Alice", "Smith", "alice@example.com", pb.SeatSection_A, 3, 50)
+	purchase(t, server, "Bob", "Jones", "bob@example.com", pb.SeatSection_B, 2, 20)
+
+	section := pb.SeatSection_A
+	minPrice := float32(30)
+	response, err := server.ListTickets(context.Background(), &pb.ListTicketsRequest{
+		Filter: &pb.TicketFilter{
+			SeatSection:   &section,
+			MinSeatNumber: 2,
+			MinPrice:      &minPrice,
+		},
+		OrderBy:    pb.TicketOrder_TICKET_ORDER_PRICE,
+		Descending: true,
+	})
+	if err != nil {
+		t.Fatalf("ListTickets failed: %v", err)
+	}
+	if len(response.Tickets) != 2 || response.Tickets[0].User.Email != "alice@example.com" || response.Tickets[1].User.Email != "jane.doe@example.com" {
+		t.Fatalf("Unexpected tickets: %v", response.Tickets)
+	}
+
+	response, err = server.ListTickets(context.Background(), &pb.ListTicketsRequest{
+		Filter:  &pb.TicketFilter{NamePrefix: "do"},
+		OrderBy: pb.TicketOrder_TICKET_ORDER_LAST_NAME,
+	})
+	if err != nil {
+		t.Fatalf("ListTickets failed: %v", err)
+	}
+	if len(response.Tickets) != 2 || response.Tickets[0].User.FirstName != "Jane" || response.Tickets[1].User.FirstName != "John" {
+		t.Fatalf("Unexpected tickets: %v", response.Tickets)
+	}
+}
+
+func TestListTicketsRejectsForeignPageToken(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	for i := 1; i <= 3; i++ {
+		purchase(t, server, "User", "Test", fmt.Sprintf("user%d@example.com", i), pb.SeatSection_A, uint32(i), 20)
+	}
+
+	ctx := context.Background()
+	response, err := server.ListTickets(ctx, &pb.ListTicketsRequest{PageSize: 1})
+	if err != nil {
+		t.Fatalf("ListTickets failed: %v", err)
+	}
+
+	_, err = server.ListTickets(ctx, &pb.ListTicketsRequest{
+		PageSize:  1,
+		PageToken: response.NextPageToken,
+		OrderBy:   pb.TicketOrder_TICKET_ORDER_EMAIL,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package apis_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/notify"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/notify/smtptest"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+func TestPassengersAreNotified(t *testing.T) {
+	sink := smtptest.NewServer()
+	defer sink.Close()
+	notifier := notify.NewNotifier(notify.DefaultTemplates(),
+		[]notify.Channel{&notify.SMTP{Addr: sink.Addr, From: "tickets@example.com"}},
+		notify.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		notifier.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	server := api.NewBookingServiceServer(api.WithNotifier(notifier))
+
+	if _, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "John.Doe@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	}); err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	// The notifier sends concurrently, so wait for each email to keep them in order.
+	if messages := sink.WaitForMessages(1, 5*time.Second); len(messages) != 1 {
+		t.Fatalf("Expected the purchase to be confirmed, got %d emails", len(messages))
+	}
+	if _, err := server.ModifyUserSeat(context.Background(), &pb.ModifyUserSeatRequest{Email: "john.doe@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 4}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	if messages := sink.WaitForMessages(2, 5*time.Second); len(messages) != 2 {
+		t.Fatalf("Expected the seat change to be notified, got %d emails", len(messages))
+	}
+	if _, err := server.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "john.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+
+	messages := sink.WaitForMessages(3, 5*time.Second)
+	expected := []string{"is booked", "has changed", "is cancelled"}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected %d emails, got %d", len(expected), len(messages))
+	}
+	for i, message := range messages {
+		// The address is the one the passenger gave, not the normalized key.
+		if len(message.To) != 1 || message.To[0] != "John.Doe@example.com" {
+			t.Errorf("Email %d: expected it to go to John.Doe@example.com, got %v", i, message.To)
+		}
+		if !strings.HasSuffix(message.Subject, expected[i]) {
+			t.Errorf("Email %d: expected a subject ending in %q, got %q", i, expected[i], message.Subject)
+		}
+	}
+	if !strings.Contains(messages[1].Body, "section A, seat 1") || !strings.Contains(messages[1].Body, "section B, seat 4") {
+		t.Errorf("Expected the seat change to show both seats, got:\n%s", messages[1].Body)
+	}
+}
+
+func TestContactDetailsAreValidated(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	response, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "Amélie", LastName: "Poulain", Email: "amelie@example.com", Phone: "+33 6 12 34 56 78", Locale: "fr-fr"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	})
+	if err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	if user := response.Ticket.User; user.Phone != "+33612345678" || user.Locale != "fr-FR" {
+		t.Errorf("Expected the phone number and locale to be normalized, got %q and %q", user.Phone, user.Locale)
+	}
+
+	for _, user := range []*pb.User{
+		{FirstName: "Jane", LastName: "Roe", Email: "jane@example.com", Phone: "07700 900123"},
+		{FirstName: "Jane", LastName: "Roe", Email: "jane@example.com", Locale: "not a locale"},
+	} {
+		if _, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{User: user, SeatSection: pb.SeatSection_A, SeatNumber: 2, TicketPrice: 20}); err == nil {
+			t.Errorf("Expected %v to be rejected", user)
+		}
+	}
+}
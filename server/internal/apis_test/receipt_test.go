@@ -0,0 +1,91 @@
+package apis_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRenderReceiptFormats(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_B, 7, 20)
+	ctx := context.Background()
+
+	tests := []struct {
+		format      pb.ReceiptFormat
+		contentType string
+		contains    string
+	}{
+		{pb.ReceiptFormat_RECEIPT_FORMAT_TEXT, "text/plain; charset=utf-8", "Section B, seat 7"},
+		{pb.ReceiptFormat_RECEIPT_FORMAT_HTML, "text/html; charset=utf-8", `<img src="data:image/png;base64,`},
+		{pb.ReceiptFormat_RECEIPT_FORMAT_PDF, "application/pdf", "%PDF-"},
+		{pb.ReceiptFormat_RECEIPT_FORMAT_E_TICKET, "application/pdf", "%PDF-"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format.String(), func(t *testing.T) {
+			response, err := server.RenderReceipt(ctx, &pb.RenderReceiptRequest{Email: "John.Doe@example.com", Format: tc.format})
+			if err != nil {
+				t.Fatalf("RenderReceipt failed: %v", err)
+			}
+			if response.ContentType != tc.contentType {
+				t.Fatalf("Unexpected content type %q", response.ContentType)
+			}
+			if !bytes.Contains(response.Document, []byte(tc.contains)) {
+				t.Fatalf("Receipt does not contain %q", tc.contains)
+			}
+			if !strings.HasPrefix(response.FileName, "ticket-") {
+				t.Fatalf("Unexpected file name %q", response.FileName)
+			}
+		})
+	}
+
+	eTicket, _ := server.RenderReceipt(ctx, &pb.RenderReceiptRequest{Email: "john.doe@example.com", Format: pb.ReceiptFormat_RECEIPT_FORMAT_E_TICKET})
+	receipt, _ := server.RenderReceipt(ctx, &pb.RenderReceiptRequest{Email: "john.doe@example.com", Format: pb.ReceiptFormat_RECEIPT_FORMAT_PDF})
+	if len(eTicket.Document) <= len(receipt.Document) {
+		t.Fatalf("E-ticket does not contain a QR code")
+	}
+}
+
+func TestRenderReceiptPayloadIsSigned(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, ed25519.SeedSize)
+	signer := signing.NewSigner(ed25519.NewKeyFromSeed(seed))
+	server := api.NewBookingServiceServer(api.WithTicketSigner(signer))
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 3, 20)
+
+	response, err := server.RenderReceipt(context.Background(), &pb.RenderReceiptRequest{Email: "john.doe@example.com"})
+	if err != nil {
+		t.Fatalf("RenderReceipt failed: %v", err)
+	}
+	claims, err := signer.Verify(response.TicketPayload)
+	if err != nil {
+		t.Fatalf("Ticket payload does not verify: %v", err)
+	}
+	if claims.Email != "john.doe@example.com" || claims.SeatSection != "A" || claims.SeatNumber != 3 || claims.TicketID == "" {
+		t.Fatalf("Unexpected claims %+v", claims)
+	}
+
+	parts := strings.Split(response.TicketPayload, ".")
+	forged := signing.NewSigner(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{8}, ed25519.SeedSize)))
+	if _, err := forged.Verify(response.TicketPayload); err == nil {
+		t.Fatalf("Payload verified with a different key")
+	}
+	if _, err := signer.Verify(parts[0] + "." + parts[1] + "x." + parts[2]); err == nil {
+		t.Fatalf("Tampered payload verified")
+	}
+}
+
+func TestRenderReceiptUnknownUser(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	_, err := server.RenderReceipt(context.Background(), &pb.RenderReceiptRequest{Email: "nobody@example.com"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package apis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func searchEmails(t *testing.T, server *api.BookingServiceServer, query string, match pb.PassengerMatch) []string {
+	t.Helper()
+	response, err := server.SearchPassengers(context.Background(), &pb.SearchPassengersRequest{Query: query, Match: match})
+	if err != nil {
+		t.Fatalf("SearchPassengers failed: %v", err)
+	}
+	var emails []string
+	for _, ticket := range response.Tickets {
+		emails = append(emails, ticket.User.Email)
+	}
+	return emails
+}
+
+func TestSearchPassengers(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	purchase(t, server, "Jane", "Doeson", "jane@mail.com", pb.SeatSection_B, 2, 20)
+	purchase(t, server, "Alice", "Smith", "alice@example.com", pb.SeatSection_A, 3, 20)
+
+	tests := []struct {
+		name     string
+		query    string
+		match    pb.PassengerMatch
+		expected []string
+	}{
+		{"surname prefix", "DOE", pb.PassengerMatch_PASSENGER_MATCH_PREFIX, []string{"john.doe@example.com", "jane@mail.com"}},
+		{"short prefix", "a", pb.PassengerMatch_PASSENGER_MATCH_PREFIX, []string{"alice@example.com"}},
+		{"email prefix", "jane@", pb.PassengerMatch_PASSENGER_MATCH_PREFIX, []string{"jane@mail.com"}},
+		{"prefix does not match middle", "example", pb.PassengerMatch_PASSENGER_MATCH_PREFIX, nil},
+		{"email substring", "Example.COM", pb.PassengerMatch_PASSENGER_MATCH_SUBSTRING, []string{"john.doe@example.com", "alice@example.com"}},
+		{"name substring", "mit", pb.PassengerMatch_PASSENGER_MATCH_SUBSTRING, []string{"alice@example.com"}},
+		{"no match", "zzz", pb.PassengerMatch_PASSENGER_MATCH_SUBSTRING, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			emails := searchEmails(t, server, tc.query, tc.match)
+			if len(emails) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, emails)
+			}
+			for i := range emails {
+				if emails[i] != tc.expected[i] {
+					t.Fatalf("Expected %v, got %v", tc.expected, emails)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchPassengersAfterRemoval(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+
+	if _, err := server.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "john.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if emails := searchEmails(t, server, "doe", pb.PassengerMatch_PASSENGER_MATCH_SUBSTRING); len(emails) != 0 {
+		t.Fatalf("Removed passenger still found: %v", emails)
+	}
+}
+
+func TestSearchPassengersPages(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	for i := 1; i <= 5; i++ {
+		purchase(t, server, "User", fmt.Sprintf("Doe%d", i), fmt.Sprintf("user%d@example.com", i), pb.SeatSection_A, uint32(i), 20)
+	}
+
+	ctx := context.Background()
+	var emails []string
+	pageToken := ""
+	for pages := 1; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("Too many pages returned")
+		}
+		response, err := server.SearchPassengers(ctx, &pb.SearchPassengersRequest{Query: "doe", PageSize: 2, PageToken: pageToken})
+		if err != nil {
+			t.Fatalf("SearchPassengers failed: %v", err)
+		}
+		for _, ticket := range response.Tickets {
+			emails = append(emails, ticket.User.Email)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+	expected := []string{"user1@example.com", "user2@example.com", "user3@example.com", "user4@example.com", "user5@example.com"}
+	if fmt.Sprint(emails) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v across pages, got %v", expected, emails)
+	}
+
+	first, err := server.SearchPassengers(ctx, &pb.SearchPassengersRequest{Query: "doe", PageSize: 2})
+	if err != nil {
+		t.Fatalf("SearchPassengers failed: %v", err)
+	}
+	_, err = server.SearchPassengers(ctx, &pb.SearchPassengersRequest{Query: "user", PageSize: 2, PageToken: first.NextPageToken})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for a page token of another query, got %v", err)
+	}
+}
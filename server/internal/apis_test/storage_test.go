@@ -0,0 +1,77 @@
+package apis_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+func TestFlushAndRestore(t *testing.T) {
+	ctx := context.Background()
+	signer := testSigner(t)
+	store := storage.NewFile(filepath.Join(t.TempDir(), "bookings.json"))
+
+	server := api.NewBookingServiceServer(api.WithStore(store), api.WithTicketSigner(signer))
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	purchase(t, server, "Jane", "Roe", "jane.roe@example.com", pb.SeatSection_B, 2, 20)
+	janePayload := signing.Payload(ticketOf(t, server, "jane.roe@example.com"))
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "jane.roe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	johnPayload := signing.Payload(ticketOf(t, server, "john.doe@example.com"))
+	if err := server.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	restarted := api.NewBookingServiceServer(api.WithStore(store), api.WithTicketSigner(signer))
+	if err := restarted.Restore(ctx); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if validity := verify(t, restarted, johnPayload).Validity; validity != pb.TicketValidity_TICKET_VALIDITY_VALID {
+		t.Errorf("Expected John's ticket to survive the restart, got %v", validity)
+	}
+	if validity := verify(t, restarted, janePayload).Validity; validity != pb.TicketValidity_TICKET_VALIDITY_CANCELLED {
+		t.Errorf("Expected Jane's ticket to stay cancelled, got %v", validity)
+	}
+	if _, err := restarted.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "Jim", LastName: "Poe", Email: "jim@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+	}); err == nil {
+		t.Errorf("Expected John's seat to still be occupied after the restart")
+	}
+	results, err := restarted.SearchPassengers(ctx, &pb.SearchPassengersRequest{Query: "doe"})
+	if err != nil || len(results.Tickets) != 1 {
+		t.Errorf("Expected the passenger index to be rebuilt, got %v, %v", results, err)
+	}
+}
+
+func TestLayoutAndLimits(t *testing.T) {
+	ctx := context.Background()
+	server := api.NewBookingServiceServer(
+		api.WithLayout(api.Layout{From: "Paris", To: "Berlin", SeatsPerSection: 2}),
+		api.WithLimits(api.Limits{MaxImportRows: 10, MaxPageSize: 1}),
+	)
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	purchase(t, server, "Jane", "Roe", "jane.roe@example.com", pb.SeatSection_A, 2, 20)
+
+	ticket := ticketOf(t, server, "john.doe@example.com")
+	if ticket.From != "Paris" || ticket.To != "Berlin" {
+		t.Errorf("Expected a Paris to Berlin ticket, got %s to %s", ticket.From, ticket.To)
+	}
+	if _, err := server.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "john.doe@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 3}); err == nil {
+		t.Errorf("Expected seat 3 to be rejected on a train with 2 seats per section")
+	}
+	response, err := server.ListTickets(ctx, &pb.ListTicketsRequest{PageSize: 50})
+	if err != nil {
+		t.Fatalf("ListTickets failed: %v", err)
+	}
+	if len(response.Tickets) != 1 || response.NextPageToken == "" {
+		t.Errorf("Expected pages of one ticket, got %d tickets", len(response.Tickets))
+	}
+}
@@ -0,0 +1,413 @@
+package apis_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const departure = "departures/london-france"
+
+// dialBothVersions serves server through both versions of the API and returns
+// a client of each.
+func dialBothVersions(t *testing.T, server *api.BookingServiceServer, opts ...grpc.ServerOption) (pb.BookingServiceClient, bookingv2.BookingServiceClient) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterBookingServiceServer(grpcServer, server)
+	bookingv2.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceV2Server(server))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBookingServiceClient(conn), bookingv2.NewBookingServiceClient(conn)
+}
+
+func createTicket(t *testing.T, client bookingv2.BookingServiceClient, email, seat string) *bookingv2.Ticket {
+	t.Helper()
+	ticket, err := client.CreateTicket(context.Background(), &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+		Seat:      departure + "/seats/" + seat,
+		Passenger: &bookingv2.Passenger{GivenName: "Pat", FamilyName: "Doe", Email: email},
+		Price:     20,
+	}})
+	if err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	return ticket
+}
+
+func TestTicketLifecycleV2(t *testing.T) {
+	_, client := dialBothVersions(t, api.NewBookingServiceServer())
+	ctx := context.Background()
+
+	created := createTicket(t, client, passengerEmail, "A7")
+	if created.Name == "" || created.Departure != departure || created.Seat != departure+"/seats/A7" ||
+		created.Passenger.GetEmail() != passengerEmail || created.Price != 20 || len(created.Signature) == 0 {
+		t.Fatalf("Unexpected ticket %v", created)
+	}
+	if _, err := client.CreateTicket(ctx, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+		Seat:      departure + "/seats/B1",
+		Passenger: &bookingv2.Passenger{Email: passengerEmail},
+	}}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists for a second ticket, got %v", err)
+	}
+
+	got, err := client.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: created.Name})
+	if err != nil || got.Seat != created.Seat {
+		t.Fatalf("Expected ticket %s, got %v and %v", created.Name, got, err)
+	}
+
+	updated, err := client.UpdateTicket(ctx, &bookingv2.UpdateTicketRequest{
+		Ticket:     &bookingv2.Ticket{Name: created.Name, Seat: departure + "/seats/B3"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"seat"}},
+	})
+	if err != nil || updated.Seat != departure+"/seats/B3" || updated.Passenger.GetEmail() != passengerEmail {
+		t.Fatalf("Expected the ticket to move to B3, got %v and %v", updated, err)
+	}
+
+	createTicket(t, client, "sam@example.com", "A1")
+	list, err := client.ListTickets(ctx, &bookingv2.ListTicketsRequest{Section: "B"})
+	if err != nil || list.TotalSize != 1 || list.Tickets[0].Name != created.Name {
+		t.Errorf("Expected only the moved ticket in section B, got %v and %v", list, err)
+	}
+	list, err = client.ListTickets(ctx, &bookingv2.ListTicketsRequest{PageSize: 1})
+	if err != nil || len(list.Tickets) != 1 || list.Tickets[0].Seat != departure+"/seats/A1" || list.NextPageToken == "" {
+		t.Fatalf("Expected the first of two pages, got %v and %v", list, err)
+	}
+	list, err = client.ListTickets(ctx, &bookingv2.ListTicketsRequest{PageSize: 1, PageToken: list.NextPageToken})
+	if err != nil || len(list.Tickets) != 1 || list.Tickets[0].Name != created.Name || list.NextPageToken != "" {
+		t.Errorf("Expected the last page, got %v and %v", list, err)
+	}
+
+	if _, err := client.DeleteTicket(ctx, &bookingv2.DeleteTicketRequest{Name: created.Name}); err != nil {
+		t.Fatalf("DeleteTicket failed: %v", err)
+	}
+	if _, err := client.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: created.Name}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for the deleted ticket, got %v", err)
+	}
+	if _, err := client.DeleteTicket(ctx, &bookingv2.DeleteTicketRequest{Name: created.Name}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound deleting twice, got %v", err)
+	}
+}
+
+func TestInvalidRequestsV2(t *testing.T) {
+	_, client := dialBothVersions(t, api.NewBookingServiceServer())
+	ctx := context.Background()
+	ticket := createTicket(t, client, passengerEmail, "A7")
+	createTicket(t, client, "sam@example.com", "A8")
+
+	create := func(seat string) error {
+		_, err := client.CreateTicket(ctx, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+			Seat:      seat,
+			Passenger: &bookingv2.Passenger{Email: "kim@example.com"},
+		}})
+		return err
+	}
+	update := func(seat string, paths ...string) error {
+		_, err := client.UpdateTicket(ctx, &bookingv2.UpdateTicketRequest{
+			Ticket:     &bookingv2.Ticket{Name: ticket.Name, Seat: seat},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+		})
+		return err
+	}
+	for _, test := range []struct {
+		name     string
+		err      error
+		expected codes.Code
+	}{
+		{"no passenger", func() error {
+			_, err := client.CreateTicket(ctx, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{Seat: departure + "/seats/A1"}})
+			return err
+		}(), codes.InvalidArgument},
+		{"no seat", create(""), codes.InvalidArgument},
+		{"malformed seat", create(departure + "/seats/7A"), codes.InvalidArgument},
+		{"unknown section", create(departure + "/seats/C1"), codes.InvalidArgument},
+		{"seat zero", create(departure + "/seats/A0"), codes.InvalidArgument},
+		{"seat out of range", create(departure + "/seats/A51"), codes.InvalidArgument},
+		{"unknown departure", create("departures/paris-rome/seats/A1"), codes.NotFound},
+		{"occupied seat", create(departure + "/seats/A8"), codes.FailedPrecondition},
+		{"moving to an occupied seat", update(departure+"/seats/A8", "seat"), codes.FailedPrecondition},
+		{"updating the passenger", update(departure+"/seats/B1", "passenger"), codes.InvalidArgument},
+		{"malformed ticket name", func() error {
+			_, err := client.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: "passengers/" + passengerEmail})
+			return err
+		}(), codes.InvalidArgument},
+		{"unknown ticket", func() error {
+			_, err := client.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: "tickets/unknown"})
+			return err
+		}(), codes.NotFound},
+		{"unknown section filter", func() error {
+			_, err := client.ListTickets(ctx, &bookingv2.ListTicketsRequest{Section: "C"})
+			return err
+		}(), codes.InvalidArgument},
+	} {
+		if status.Code(test.err) != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, test.err)
+		}
+	}
+}
+
+func TestDeparturesAndSeats(t *testing.T) {
+	server := api.NewBookingServiceServer(api.WithLayout(api.Layout{From: "London", To: "France", SeatsPerSection: 3}))
+	_, client := dialBothVersions(t, server)
+	ctx := context.Background()
+	createTicket(t, client, passengerEmail, "A2")
+
+	got, err := client.GetDeparture(ctx, &bookingv2.GetDepartureRequest{Name: departure})
+	if err != nil || got.Origin != "London" || got.Destination != "France" || got.SeatsPerSection != 3 ||
+		len(got.Sections) != 2 || got.AvailableSeats != 5 {
+		t.Fatalf("Unexpected departure %v and %v", got, err)
+	}
+	if _, err := client.GetDeparture(ctx, &bookingv2.GetDepartureRequest{Name: "departures/paris-rome"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown departure, got %v", err)
+	}
+	departures, err := client.ListDepartures(ctx, &bookingv2.ListDeparturesRequest{})
+	if err != nil || len(departures.Departures) != 1 || departures.Departures[0].Name != departure {
+		t.Errorf("Expected the one departure, got %v and %v", departures, err)
+	}
+
+	var names []string
+	pageToken := ""
+	for pages := 0; ; pages++ {
+		if pages > 6 {
+			t.Fatalf("Pagination did not terminate")
+		}
+		page, err := client.ListSeats(ctx, &bookingv2.ListSeatsRequest{Parent: departure, PageSize: 2, PageToken: pageToken, AvailableOnly: true})
+		if err != nil {
+			t.Fatalf("ListSeats failed: %v", err)
+		}
+		for _, seat := range page.Seats {
+			names = append(names, seat.Section+string(rune('0'+seat.Number)))
+		}
+		if pageToken = page.NextPageToken; pageToken == "" {
+			break
+		}
+	}
+	if got := len(names); got != 5 || names[0] != "A1" || names[1] != "A3" || names[4] != "B3" {
+		t.Errorf("Expected the five free seats in order, got %v", names)
+	}
+
+	all, err := client.ListSeats(ctx, &bookingv2.ListSeatsRequest{Parent: departure})
+	if err != nil || len(all.Seats) != 6 || all.Seats[1].Available || all.Seats[1].Name != departure+"/seats/A2" {
+		t.Errorf("Expected A2 to be listed as taken, got %v and %v", all, err)
+	}
+	if _, err := client.ListSeats(ctx, &bookingv2.ListSeatsRequest{Parent: departure, PageToken: pageToken + "x"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a bad page token, got %v", err)
+	}
+}
+
+func TestVersionsShareBookings(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	v1, v2 := dialBothVersions(t, server)
+	ctx := context.Background()
+
+	// Bought through v1, read, moved and cancelled through v2.
+	purchased, err := v1.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "john@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	})
+	if err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	name := "tickets/" + purchased.Ticket.Id
+	got, err := v2.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: name})
+	if err != nil || got.Seat != departure+"/seats/A1" || got.Passenger.GetGivenName() != "John" {
+		t.Fatalf("Expected the v1 ticket through v2, got %v and %v", got, err)
+	}
+	if _, err := v2.UpdateTicket(ctx, &bookingv2.UpdateTicketRequest{Ticket: &bookingv2.Ticket{Name: name, Seat: departure + "/seats/B2"}}); err != nil {
+		t.Fatalf("UpdateTicket failed: %v", err)
+	}
+	receipt, err := v1.GetReceipt(ctx, &pb.GetReceiptRequest{Email: "john@example.com"})
+	if err != nil || receipt.Ticket.SeatSection != pb.SeatSection_B || receipt.Ticket.SeatNumber != 2 {
+		t.Errorf("Expected the v2 seat change through v1, got %v and %v", receipt, err)
+	}
+	if _, err := v2.DeleteTicket(ctx, &bookingv2.DeleteTicketRequest{Name: name}); err != nil {
+		t.Fatalf("DeleteTicket failed: %v", err)
+	}
+	if _, err := v1.RenderReceipt(ctx, &pb.RenderReceiptRequest{Email: "john@example.com"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected the v2 cancellation through v1, got %v", err)
+	}
+
+	// Bought through v2, read, moved and cancelled through v1.
+	created := createTicket(t, v2, passengerEmail, "A3")
+	if _, err := v1.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{User: &pb.User{Email: "kim@example.com"}, SeatSection: pb.SeatSection_A, SeatNumber: 3}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected the v2 seat to be taken through v1, got %v", err)
+	}
+	receipt, err = v1.GetReceipt(ctx, &pb.GetReceiptRequest{Email: passengerEmail})
+	if err != nil || "tickets/"+receipt.Ticket.Id != created.Name {
+		t.Fatalf("Expected the v2 ticket through v1, got %v and %v", receipt, err)
+	}
+	if _, err := v1.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: passengerEmail, NewSeatSection: pb.SeatSection_B, NewSeatNumber: 9}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	if got, err := v2.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: created.Name}); err != nil || got.Seat != departure+"/seats/B9" {
+		t.Errorf("Expected the v1 seat change through v2, got %v and %v", got, err)
+	}
+	if _, err := v1.RemoveUser(ctx, &pb.RemoveUserRequest{Email: passengerEmail}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if _, err := v2.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: created.Name}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected the v1 cancellation through v2, got %v", err)
+	}
+
+	// Every change is audited under the method that made it.
+	expected := []string{
+		"/BookingService.BookingService/PurchaseTicket",
+		"/bookmyseat.booking.v2.BookingService/UpdateTicket",
+		"/bookmyseat.booking.v2.BookingService/DeleteTicket",
+		"/bookmyseat.booking.v2.BookingService/CreateTicket",
+		"/BookingService.BookingService/ModifyUserSeat",
+		"/BookingService.BookingService/RemoveUser",
+	}
+	events := listAuditEvents(t, server, &pb.ListAuditEventsRequest{}).Events
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d audit events, got %v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.Method != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i+1, expected[i], event.Method)
+		}
+	}
+}
+
+func TestPassengersOnlyAccessTheirOwnTicketsV2(t *testing.T) {
+	server := api.NewBookingServiceServer()
+	v2 := api.NewBookingServiceV2Server(server)
+	passenger := auth.NewContext(context.Background(), &auth.Identity{Subject: "pat", Email: passengerEmail, Roles: []string{auth.RolePassenger}})
+
+	own, err := v2.CreateTicket(passenger, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+		Seat:      departure + "/seats/A1",
+		Passenger: &bookingv2.Passenger{Email: passengerEmail},
+	}})
+	if err != nil {
+		t.Fatalf("Expected a passenger to book for themselves, got %v", err)
+	}
+	if _, err := v2.CreateTicket(passenger, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+		Seat:      departure + "/seats/A2",
+		Passenger: &bookingv2.Passenger{Email: "sam@example.com"},
+	}}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied booking for someone else, got %v", err)
+	}
+	other, err := v2.CreateTicket(context.Background(), &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+		Seat:      departure + "/seats/A2",
+		Passenger: &bookingv2.Passenger{Email: "sam@example.com"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+
+	if _, err := v2.GetTicket(passenger, &bookingv2.GetTicketRequest{Name: own.Name}); err != nil {
+		t.Errorf("Expected a passenger to read their own ticket, got %v", err)
+	}
+	if _, err := v2.GetTicket(passenger, &bookingv2.GetTicketRequest{Name: other.Name}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied reading another passenger's ticket, got %v", err)
+	}
+}
+
+// rpcCallsV2 issues one minimal call of every v2 BookingService RPC, on behalf
+// of passengerEmail, who holds the ticket at seat A1.
+var rpcCallsV2 = map[string]func(ctx context.Context, client bookingv2.BookingServiceClient, ticket string) error{
+	"GetDeparture": func(ctx context.Context, client bookingv2.BookingServiceClient, _ string) error {
+		_, err := client.GetDeparture(ctx, &bookingv2.GetDepartureRequest{Name: departure})
+		return err
+	},
+	"ListDepartures": func(ctx context.Context, client bookingv2.BookingServiceClient, _ string) error {
+		_, err := client.ListDepartures(ctx, &bookingv2.ListDeparturesRequest{})
+		return err
+	},
+	"ListSeats": func(ctx context.Context, client bookingv2.BookingServiceClient, _ string) error {
+		_, err := client.ListSeats(ctx, &bookingv2.ListSeatsRequest{Parent: departure})
+		return err
+	},
+	"CreateTicket": func(ctx context.Context, client bookingv2.BookingServiceClient, _ string) error {
+		_, err := client.CreateTicket(ctx, &bookingv2.CreateTicketRequest{Ticket: &bookingv2.Ticket{
+			Seat:      departure + "/seats/B7",
+			Passenger: &bookingv2.Passenger{Email: passengerEmail},
+		}})
+		return err
+	},
+	"GetTicket": func(ctx context.Context, client bookingv2.BookingServiceClient, ticket string) error {
+		_, err := client.GetTicket(ctx, &bookingv2.GetTicketRequest{Name: ticket})
+		return err
+	},
+	"ListTickets": func(ctx context.Context, client bookingv2.BookingServiceClient, _ string) error {
+		_, err := client.ListTickets(ctx, &bookingv2.ListTicketsRequest{})
+		return err
+	},
+	"UpdateTicket": func(ctx context.Context, client bookingv2.BookingServiceClient, ticket string) error {
+		_, err := client.UpdateTicket(ctx, &bookingv2.UpdateTicketRequest{Ticket: &bookingv2.Ticket{Name: ticket, Seat: departure + "/seats/B2"}})
+		return err
+	},
+	"DeleteTicket": func(ctx context.Context, client bookingv2.BookingServiceClient, ticket string) error {
+		_, err := client.DeleteTicket(ctx, &bookingv2.DeleteTicketRequest{Name: ticket})
+		return err
+	},
+}
+
+// allowedRolesV2 is the expected policy of the v2 API, written out
+// independently of api.Policy.
+var allowedRolesV2 = map[string][]string{
+	"GetDeparture":   {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"ListDepartures": {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"ListSeats":      {auth.RolePassenger, auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"CreateTicket":   {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	"GetTicket":      {auth.RolePassenger, auth.RoleAgent, auth.RoleAdmin},
+	"ListTickets":    {auth.RoleAgent, auth.RoleConductor, auth.RoleAdmin},
+	"UpdateTicket":   {auth.RoleAdmin},
+	"DeleteTicket":   {auth.RoleAdmin},
+}
+
+func TestAuthorizationMatrixV2(t *testing.T) {
+	var keys []auth.APIKey
+	for _, role := range allRoles {
+		key := auth.APIKey{Name: role, KeySHA256: auth.HashAPIKey("key-" + role), Email: passengerEmail}
+		if role != "none" {
+			key.Roles = []string{role}
+		}
+		keys = append(keys, key)
+	}
+	authenticator := auth.NewAuthenticator(auth.WithAPIKeys(keys), auth.WithPolicy(api.Policy))
+
+	for method, call := range rpcCallsV2 {
+		for _, role := range allRoles {
+			allowed := false
+			for _, allowedRole := range allowedRolesV2[method] {
+				allowed = allowed || allowedRole == role
+			}
+			t.Run(method+"/"+role, func(t *testing.T) {
+				server := api.NewBookingServiceServer()
+				purchase(t, server, "Pat", "Doe", passengerEmail, pb.SeatSection_A, 1, 20)
+				_, client := dialBothVersions(t, server, grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()))
+				ctx := metadata.AppendToOutgoingContext(context.Background(), auth.APIKeyHeader, "key-"+role)
+				err := call(ctx, client, "tickets/"+ticketOf(t, server, passengerEmail).Id)
+				denied := status.Code(err) == codes.PermissionDenied
+				if allowed && denied {
+					t.Errorf("Expected %s to be allowed to call %s, got %v", role, method, err)
+				}
+				if !allowed && !denied {
+					t.Errorf("Expected PermissionDenied for %s calling %s, got %v", role, method, err)
+				}
+			})
+		}
+	}
+}
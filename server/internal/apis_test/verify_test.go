@@ -0,0 +1,120 @@
+package apis_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// testSigner writes a fresh Ed25519 key to a PEM file and loads it back the way the server does at startup.
+func testSigner(t *testing.T) *signing.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating key failed: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Encoding key failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Writing key failed: %v", err)
+	}
+	signer, err := signing.LoadSigner(path)
+	if err != nil {
+		t.Fatalf("LoadSigner failed: %v", err)
+	}
+	return signer
+}
+
+func verify(t *testing.T, server *api.BookingServiceServer, payload string) *pb.VerifyTicketResponse {
+	t.Helper()
+	response, err := server.VerifyTicket(context.Background(), &pb.VerifyTicketRequest{TicketPayload: payload})
+	if err != nil {
+		t.Fatalf("VerifyTicket failed: %v", err)
+	}
+	return response
+}
+
+func TestPurchasedTicketIsSigned(t *testing.T) {
+	signer := testSigner(t)
+	server := api.NewBookingServiceServer(api.WithTicketSigner(signer))
+
+	response, err := server.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	})
+	if err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	if err := signer.VerifyTicket(response.Ticket); err != nil {
+		t.Fatalf("Purchased ticket signature does not verify: %v", err)
+	}
+	response.Ticket.SeatNumber = 2
+	if err := signer.VerifyTicket(response.Ticket); err == nil {
+		t.Fatalf("Altered ticket signature verified")
+	}
+}
+
+func TestVerifyTicket(t *testing.T) {
+	signer := testSigner(t)
+	server := api.NewBookingServiceServer(api.WithTicketSigner(signer))
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	ctx := context.Background()
+
+	receipt, err := server.GetReceipt(ctx, &pb.GetReceiptRequest{Email: "john.doe@example.com"})
+	if err != nil {
+		t.Fatalf("GetReceipt failed: %v", err)
+	}
+	payload := signing.Payload(receipt.Ticket)
+
+	if response := verify(t, server, payload); response.Validity != pb.TicketValidity_TICKET_VALIDITY_VALID || response.Ticket.SeatNumber != 1 {
+		t.Fatalf("Unexpected verification result: %v", response)
+	}
+
+	if _, err := server.ModifyUserSeat(ctx, &pb.ModifyUserSeatRequest{Email: "john.doe@example.com", NewSeatSection: pb.SeatSection_B, NewSeatNumber: 4}); err != nil {
+		t.Fatalf("ModifyUserSeat failed: %v", err)
+	}
+	response := verify(t, server, payload)
+	if response.Validity != pb.TicketValidity_TICKET_VALIDITY_MOVED || response.Ticket.SeatSection != pb.SeatSection_B || response.Ticket.SeatNumber != 4 {
+		t.Fatalf("Unexpected verification result after seat change: %v", response)
+	}
+	if newPayload := signing.Payload(response.Ticket); verify(t, server, newPayload).Validity != pb.TicketValidity_TICKET_VALIDITY_VALID {
+		t.Fatalf("Re-signed ticket does not verify")
+	}
+
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "john.doe@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if response := verify(t, server, payload); response.Validity != pb.TicketValidity_TICKET_VALIDITY_CANCELLED || response.Ticket != nil {
+		t.Fatalf("Unexpected verification result after removal: %v", response)
+	}
+}
+
+func TestVerifyTicketRejectsForgery(t *testing.T) {
+	server := api.NewBookingServiceServer(api.WithTicketSigner(testSigner(t)))
+	purchase(t, server, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+
+	// A ticket signed by another key for the same seat
+	forger := api.NewBookingServiceServer(api.WithTicketSigner(testSigner(t)))
+	purchase(t, forger, "John", "Doe", "john.doe@example.com", pb.SeatSection_A, 1, 20)
+	receipt, _ := forger.GetReceipt(context.Background(), &pb.GetReceiptRequest{Email: "john.doe@example.com"})
+
+	for _, payload := range []string{"", "garbage", signing.Payload(receipt.Ticket)} {
+		if response := verify(t, server, payload); response.Validity != pb.TicketValidity_TICKET_VALIDITY_FORGED {
+			t.Fatalf("Expected %q to be rejected as forged, got %v", payload, response)
+		}
+	}
+}
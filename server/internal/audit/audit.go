@@ -0,0 +1,118 @@
+// Package audit keeps an append-only log of the changes made to bookings. Every
+// event carries the SHA-256 hash of the event before it and a hash over itself,
+// so editing, removing or reordering events breaks the chain and is detected
+// by Verify.
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Log is a hash-chained list of audit events. A Log is not safe for concurrent
+// use; the booking server guards it with its own lock.
+type Log struct {
+	events []*pb.AuditEvent
+	// broken is the first event failing verification, nil while the chain is
+	// intact. The chain is verified once on load and then event by event as
+	// they are appended, so it is never walked again.
+	broken *BrokenError
+}
+
+// NewLog returns a log continuing events, as loaded from storage. The events are
+// kept as they are even if they fail verification, so that the damage stays
+// visible.
+func NewLog(events []*pb.AuditEvent) *Log {
+	l := &Log{events: events}
+	if err := Verify(events); err != nil {
+		l.broken = err.(*BrokenError)
+	}
+	return l
+}
+
+// Append completes event with its sequence number and hashes and adds it to
+// the log. The log keeps event, callers must not modify it afterwards.
+func (l *Log) Append(event *pb.AuditEvent) {
+	event.Sequence = uint64(len(l.events) + 1)
+	var previous []byte
+	if len(l.events) > 0 {
+		previous = l.events[len(l.events)-1].Hash
+	}
+	event.PreviousHash = previous
+	event.Hash = Hash(event)
+	if l.broken == nil {
+		l.broken = verifyEvent(event, event.Sequence, previous)
+	}
+	l.events = append(l.events, event)
+}
+
+// Events returns the events of the log, oldest first. The events must not be
+// modified.
+func (l *Log) Events() []*pb.AuditEvent {
+	return l.events
+}
+
+// Hash returns the SHA-256 hash of event, ignoring its hash field.
+func Hash(event *pb.AuditEvent) []byte {
+	unhashed := proto.Clone(event).(*pb.AuditEvent)
+	unhashed.Hash = nil
+	// Deterministic marshalling makes the encoding, and thus the hash, stable
+	// for equal events.
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(unhashed)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(raw)
+	return sum[:]
+}
+
+// BrokenError reports the first event failing verification.
+type BrokenError struct {
+	Sequence uint64
+	Reason   string
+}
+
+func (e *BrokenError) Error() string {
+	return fmt.Sprintf("audit event %d: %s", e.Sequence, e.Reason)
+}
+
+// Verify checks that events are numbered from 1 without gaps, that every event
+// matches its hash and that every event links to the hash of the one before
+// it. It returns a *BrokenError for the first event that does not.
+func Verify(events []*pb.AuditEvent) error {
+	var previous []byte
+	for i, event := range events {
+		if broken := verifyEvent(event, uint64(i+1), previous); broken != nil {
+			return broken
+		}
+		previous = event.Hash
+	}
+	return nil
+}
+
+// verifyEvent checks event as the one numbered sequence, following an event
+// hashed to previous.
+func verifyEvent(event *pb.AuditEvent, sequence uint64, previous []byte) *BrokenError {
+	switch {
+	case event.Sequence != sequence:
+		return &BrokenError{Sequence: sequence, Reason: fmt.Sprintf("found sequence %d", event.Sequence)}
+	case !bytes.Equal(event.PreviousHash, previous):
+		return &BrokenError{Sequence: sequence, Reason: "does not link to the previous event"}
+	case !bytes.Equal(event.Hash, Hash(event)):
+		return &BrokenError{Sequence: sequence, Reason: "does not match its hash"}
+	}
+	return nil
+}
+
+// Verify reports the first event of the log failing verification, see Verify.
+// The result is kept up to date as events are appended, so Verify is cheap.
+func (l *Log) Verify() error {
+	if l.broken == nil {
+		return nil
+	}
+	return l.broken
+}
@@ -0,0 +1,124 @@
+package audit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/audit"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func sampleLog() *audit.Log {
+	log := audit.NewLog(nil)
+	for seat := uint32(1); seat <= 3; seat++ {
+		log.Append(&pb.AuditEvent{
+			Actor:    "agent-1",
+			Method:   "/BookingService.BookingService/ModifyUserSeat",
+			TicketId: "ticket-1",
+			Before:   &pb.Ticket{Id: "ticket-1", SeatNumber: seat},
+			After:    &pb.Ticket{Id: "ticket-1", SeatNumber: seat + 1},
+		})
+	}
+	return log
+}
+
+func copyEvents(log *audit.Log) []*pb.AuditEvent {
+	var events []*pb.AuditEvent
+	for _, event := range log.Events() {
+		events = append(events, proto.Clone(event).(*pb.AuditEvent))
+	}
+	return events
+}
+
+func TestAppendChainsEvents(t *testing.T) {
+	log := sampleLog()
+	events := log.Events()
+	for i, event := range events {
+		if event.Sequence != uint64(i+1) {
+			t.Errorf("Expected event %d to have sequence %d, got %d", i, i+1, event.Sequence)
+		}
+		if len(event.Hash) != 32 {
+			t.Errorf("Expected a SHA-256 hash, got %x", event.Hash)
+		}
+	}
+	if len(events[0].PreviousHash) != 0 {
+		t.Errorf("Expected the first event not to link anywhere")
+	}
+	if string(events[2].PreviousHash) != string(events[1].Hash) {
+		t.Errorf("Expected every event to link to the one before it")
+	}
+	if err := log.Verify(); err != nil {
+		t.Errorf("Expected the log to verify, got %v", err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := map[string]struct {
+		tamper func([]*pb.AuditEvent) []*pb.AuditEvent
+		broken uint64
+	}{
+		"edited event": {
+			tamper: func(events []*pb.AuditEvent) []*pb.AuditEvent {
+				events[1].After.SeatNumber = 42
+				return events
+			},
+			broken: 2,
+		},
+		"edited and rehashed event": {
+			tamper: func(events []*pb.AuditEvent) []*pb.AuditEvent {
+				events[1].Actor = "someone-else"
+				events[1].Hash = audit.Hash(events[1])
+				return events
+			},
+			broken: 3,
+		},
+		"removed event": {
+			tamper: func(events []*pb.AuditEvent) []*pb.AuditEvent {
+				return append(events[:1], events[2:]...)
+			},
+			broken: 2,
+		},
+		"reordered events": {
+			tamper: func(events []*pb.AuditEvent) []*pb.AuditEvent {
+				events[1], events[2] = events[2], events[1]
+				return events
+			},
+			broken: 2,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := audit.Verify(test.tamper(copyEvents(sampleLog())))
+			var broken *audit.BrokenError
+			if !errors.As(err, &broken) {
+				t.Fatalf("Expected tampering to be detected, got %v", err)
+			}
+			if broken.Sequence != test.broken {
+				t.Errorf("Expected event %d to be reported, got %v", test.broken, err)
+			}
+		})
+	}
+}
+
+func TestLoadedLogContinuesChain(t *testing.T) {
+	log := audit.NewLog(copyEvents(sampleLog()))
+	log.Append(&pb.AuditEvent{Actor: "admin", TicketId: "ticket-2", After: &pb.Ticket{Id: "ticket-2"}})
+	if err := log.Verify(); err != nil {
+		t.Errorf("Expected the continued log to verify, got %v", err)
+	}
+	if last := log.Events()[3]; last.Sequence != 4 {
+		t.Errorf("Expected the new event to get sequence 4, got %d", last.Sequence)
+	}
+}
+
+func TestLoadedLogReportsFirstBrokenEvent(t *testing.T) {
+	events := copyEvents(sampleLog())
+	events[1].After.SeatNumber = 42
+	log := audit.NewLog(events)
+	log.Append(&pb.AuditEvent{Actor: "admin", TicketId: "ticket-2", After: &pb.Ticket{Id: "ticket-2"}})
+	var broken *audit.BrokenError
+	if err := log.Verify(); !errors.As(err, &broken) || broken.Sequence != 2 {
+		t.Errorf("Expected event 2 to stay reported after appending, got %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package auth authenticates gRPC callers. Callers present either a bearer token
+// (a JWT signed by a key in a local key set) or an API key in the request
+// metadata; the resulting Identity is stored in the context for handlers.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/grpcutil"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// AuthorizationHeader carries "Bearer <JWT>".
+	AuthorizationHeader = "authorization"
+	// APIKeyHeader carries a raw API key.
+	APIKeyHeader = "x-api-key"
+)
+
+// Identity describes an authenticated caller.
+type Identity struct {
+	// Subject is the JWT "sub" claim or the name of the API key.
+	Subject string
+	// Email is the caller's email address, if known.
+	Email string
+	// Roles granted to the caller.
+	Roles []string
+	// Method is how the caller authenticated: "jwt", "api-key" or "mtls".
+	Method string
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying identity.
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity of the caller, if the request was authenticated.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+// APIKey is an API key accepted by the server. Only the SHA-256 hash of the key
+// is kept so that key files do not hold usable secrets.
+type APIKey struct {
+	Name      string   `json:"name"`
+	KeySHA256 string   `json:"key_sha256"`
+	Email     string   `json:"email,omitempty"`
+	Roles     []string `json:"roles"`
+}
+
+// Authenticator verifies the credentials of incoming requests.
+type Authenticator struct {
+	keys        *KeySet
+	apiKeys     []APIKey
+	issuer      string
+	audience    string
+	policy      Policy
+	clientCerts bool
+	public      []string
+}
+
+// Option configures an Authenticator.
+type Option func(*Authenticator)
+
+// WithKeySet accepts bearer tokens signed by a key in keys.
+func WithKeySet(keys *KeySet) Option {
+	return func(a *Authenticator) {
+		a.keys = keys
+	}
+}
+
+// WithAPIKeys accepts the given API keys.
+func WithAPIKeys(keys []APIKey) Option {
+	return func(a *Authenticator) {
+		a.apiKeys = append(a.apiKeys, keys...)
+	}
+}
+
+// WithIssuer requires bearer tokens to carry the given "iss" claim.
+func WithIssuer(issuer string) Option {
+	return func(a *Authenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience requires bearer tokens to list audience in their "aud" claim.
+func WithAudience(audience string) Option {
+	return func(a *Authenticator) {
+		a.audience = audience
+	}
+}
+
+// WithPolicy additionally requires callers to hold a role the policy allows for
+// the method being called.
+func WithPolicy(policy Policy) Option {
+	return func(a *Authenticator) {
+		a.policy = policy
+	}
+}
+
+// WithClientCertificates accepts callers presenting a client certificate that
+// the TLS layer verified. The certificate's common name becomes the subject and
+// its organizational units the roles, so admin tooling can be issued
+// certificates instead of tokens.
+func WithClientCertificates() Option {
+	return func(a *Authenticator) {
+		a.clientCerts = true
+	}
+}
+
+// WithPublicServices lets anyone call the given fully qualified services, such
+// as the health service orchestrators probe without credentials.
+func WithPublicServices(services ...string) Option {
+	return func(a *Authenticator) {
+		a.public = append(a.public, services...)
+	}
+}
+
+// NewAuthenticator returns an Authenticator accepting the configured credentials.
+func NewAuthenticator(opts ...Option) *Authenticator {
+	a := &Authenticator{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// claims are the JWT claims understood by the server.
+type claims struct {
+	jwt.RegisteredClaims
+	Email string   `json:"email,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Authenticate returns the identity proven by the credentials in the incoming
+// metadata of ctx, or an Unauthenticated error.
+func (a *Authenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(AuthorizationHeader); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if !found || !strings.EqualFold(scheme, "bearer") {
+			return nil, status.Errorf(codes.Unauthenticated, "authorization header must use the Bearer scheme")
+		}
+		return a.verifyToken(strings.TrimSpace(token))
+	}
+	if values := md.Get(APIKeyHeader); len(values) > 0 {
+		return a.verifyAPIKey(values[0])
+	}
+	if identity, ok := a.verifyClientCertificate(ctx); ok {
+		return identity, nil
+	}
+	return nil, status.Errorf(codes.Unauthenticated, "missing credentials: send a bearer token or an API key")
+}
+
+func (a *Authenticator) verifyToken(token string) (*Identity, error) {
+	if a.keys == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "bearer tokens are not accepted")
+	}
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(a.keys.Algorithms()),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(a.audience))
+	}
+	var tokenClaims claims
+	if _, err := jwt.ParseWithClaims(token, &tokenClaims, a.keys.keyFunc, parserOpts...); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid bearer token: %v", err)
+	}
+	if tokenClaims.Subject == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid bearer token: missing subject")
+	}
+	return &Identity{
+		Subject: tokenClaims.Subject,
+		Email:   tokenClaims.Email,
+		Roles:   tokenClaims.Roles,
+		Method:  "jwt",
+	}, nil
+}
+
+func (a *Authenticator) verifyAPIKey(key string) (*Identity, error) {
+	sum := sha256.Sum256([]byte(key))
+	for _, apiKey := range a.apiKeys {
+		expected, err := hex.DecodeString(apiKey.KeySHA256)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(sum[:], expected) == 1 {
+			return &Identity{Subject: apiKey.Name, Email: apiKey.Email, Roles: apiKey.Roles, Method: "api-key"}, nil
+		}
+	}
+	return nil, status.Errorf(codes.Unauthenticated, "invalid API key")
+}
+
+// authorize authenticates the caller of fullMethod and checks the policy, if any.
+// Calls to public services return no identity and no error.
+func (a *Authenticator) authorize(ctx context.Context, fullMethod string) (*Identity, error) {
+	for _, service := range a.public {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return nil, nil
+		}
+	}
+	identity, err := a.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.policy != nil {
+		if err := a.policy.Authorize(fullMethod, identity); err != nil {
+			return nil, err
+		}
+	}
+	return identity, nil
+}
+
+func (a *Authenticator) verifyClientCertificate(ctx context.Context) (*Identity, bool) {
+	if !a.clientCerts {
+		return nil, false
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	leaf := tlsInfo.State.VerifiedChains[0][0]
+	return &Identity{Subject: leaf.Subject.CommonName, Roles: leaf.Subject.OrganizationalUnit, Method: "mtls"}, true
+}
+
+// UnaryInterceptor rejects unary calls without valid credentials, or by callers
+// the policy does not allow, and stores the caller's identity in the context
+// passed to the handler.
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		if identity == nil {
+			return handler(ctx, req)
+		}
+		return handler(NewContext(ctx, identity), req)
+	}
+}
+
+// StreamInterceptor is the streaming counterpart of UnaryInterceptor.
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		identity, err := a.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		if identity == nil {
+			return handler(srv, stream)
+		}
+		return handler(srv, grpcutil.WithContext(stream, NewContext(stream.Context(), identity)))
+	}
+}
+
+// HashAPIKey returns the value to store as KeySHA256 for key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (i *Identity) String() string {
+	return fmt.Sprintf("%s (%s)", i.Subject, i.Method)
+}
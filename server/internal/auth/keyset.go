@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the keys that bearer tokens may be signed with, indexed by key ID.
+type KeySet struct {
+	keys map[string]verificationKey
+}
+
+type verificationKey struct {
+	key        interface{}
+	algorithms []string
+}
+
+// jsonWebKey is the subset of RFC 7517 fields used by the supported key types.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadKeySet reads a JSON Web Key Set from path. RSA, EC (P-256, P-384, P-521),
+// OKP (Ed25519) and symmetric (oct) keys are supported.
+func LoadKeySet(path string) (*KeySet, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key set: %v", err)
+	}
+	return ParseKeySet(raw)
+}
+
+// ParseKeySet parses a JSON Web Key Set.
+func ParseKeySet(raw []byte) (*KeySet, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("parsing key set: %v", err)
+	}
+	if len(document.Keys) == 0 {
+		return nil, fmt.Errorf("key set contains no keys")
+	}
+	set := &KeySet{keys: make(map[string]verificationKey)}
+	for i, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d (%q): %v", i, jwk.Kid, err)
+		}
+		if _, duplicate := set.keys[jwk.Kid]; duplicate {
+			return nil, fmt.Errorf("duplicate key ID %q", jwk.Kid)
+		}
+		set.keys[jwk.Kid] = key
+	}
+	return set, nil
+}
+
+func (jwk jsonWebKey) verificationKey() (verificationKey, error) {
+	var key verificationKey
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return key, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return key, err
+		}
+		key.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		key.algorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case "EC":
+		curves := map[string]struct {
+			curve elliptic.Curve
+			alg   string
+		}{
+			"P-256": {elliptic.P256(), "ES256"},
+			"P-384": {elliptic.P384(), "ES384"},
+			"P-521": {elliptic.P521(), "ES512"},
+		}
+		curve, ok := curves[jwk.Crv]
+		if !ok {
+			return key, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return key, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return key, err
+		}
+		key.key = &ecdsa.PublicKey{Curve: curve.curve, X: x, Y: y}
+		key.algorithms = []string{curve.alg}
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return key, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return key, fmt.Errorf("invalid Ed25519 public key")
+		}
+		key.key = ed25519.PublicKey(x)
+		key.algorithms = []string{"EdDSA"}
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(secret) == 0 {
+			return key, fmt.Errorf("invalid symmetric key")
+		}
+		key.key = secret
+		key.algorithms = []string{"HS256", "HS384", "HS512"}
+	default:
+		return key, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+	if jwk.Alg != "" {
+		allowed := false
+		for _, alg := range key.algorithms {
+			allowed = allowed || alg == jwk.Alg
+		}
+		if !allowed {
+			return key, fmt.Errorf("algorithm %s cannot be used with a %s key", jwk.Alg, jwk.Kty)
+		}
+		key.algorithms = []string{jwk.Alg}
+	}
+	return key, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// Algorithms lists every signing algorithm accepted by some key of the set.
+func (s *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algorithms []string
+	for _, key := range s.keys {
+		for _, alg := range key.algorithms {
+			if !seen[alg] {
+				seen[alg] = true
+				algorithms = append(algorithms, alg)
+			}
+		}
+	}
+	sort.Strings(algorithms)
+	return algorithms
+}
+
+// keyFunc selects the key named by the token's "kid" header, falling back to the
+// only key of the set for tokens without one.
+func (s *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok && kid == "" && len(s.keys) == 1 {
+		for _, only := range s.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	for _, alg := range key.algorithms {
+		if alg == token.Method.Alg() {
+			return key.key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q cannot verify %s tokens", kid, token.Method.Alg())
+}
+
+// LoadAPIKeys reads a JSON array of APIKey entries from path.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading API keys: %v", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("parsing API keys: %v", err)
+	}
+	for _, key := range keys {
+		if key.Name == "" || len(key.KeySHA256) != 64 {
+			return nil, fmt.Errorf("API key entries need a name and a hex encoded key_sha256")
+		}
+	}
+	return keys, nil
+}
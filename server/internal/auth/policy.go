@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Roles understood by the server.
+const (
+	// RolePassenger may book and view their own tickets.
+	RolePassenger = "passenger"
+	// RoleAgent books and looks up tickets on behalf of passengers.
+	RoleAgent = "agent"
+	// RoleConductor checks passengers in on board.
+	RoleConductor = "conductor"
+	// RoleAdmin may call every RPC.
+	RoleAdmin = "admin"
+)
+
+// Policy maps full gRPC method names ("/package.Service/Method") to the roles
+// allowed to call them. Methods missing from the policy are denied to everyone.
+type Policy map[string][]string
+
+// Authorize returns a PermissionDenied error unless identity holds one of the
+// roles allowed to call fullMethod.
+func (p Policy) Authorize(fullMethod string, identity *Identity) error {
+	allowed, listed := p[fullMethod]
+	if !listed {
+		return status.Errorf(codes.PermissionDenied, "%s is not available to any role", fullMethod)
+	}
+	if identity != nil && identity.HasAnyRole(allowed...) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "%s requires one of the roles %v", fullMethod, allowed)
+}
+
+// HasAnyRole reports whether the identity holds at least one of roles.
+func (i *Identity) HasAnyRole(roles ...string) bool {
+	for _, held := range i.Roles {
+		for _, role := range roles {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the identity acts on behalf of the operator rather
+// than as a passenger, and may therefore access other people's bookings.
+func (i *Identity) IsStaff() bool {
+	return i.HasAnyRole(RoleAgent, RoleConductor, RoleAdmin)
+}
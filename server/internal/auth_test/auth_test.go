@@ -0,0 +1,252 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/readiness"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testAPIKey = "s3cret-api-key"
+
+// testAuthenticator returns an authenticator trusting a fresh Ed25519 key with
+// ID "test" and the API key testAPIKey, along with the private key.
+func testAuthenticator(t *testing.T) (*auth.Authenticator, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks := fmt.Sprintf(`{"keys": [{"kty": "OKP", "crv": "Ed25519", "kid": "test", "x": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(public))
+	keys, err := auth.ParseKeySet([]byte(jwks))
+	if err != nil {
+		t.Fatalf("ParseKeySet failed: %v", err)
+	}
+	authenticator := auth.NewAuthenticator(
+		auth.WithKeySet(keys),
+		auth.WithIssuer("book-my-seat-test"),
+		auth.WithAPIKeys([]auth.APIKey{{Name: "ops", KeySHA256: auth.HashAPIKey(testAPIKey), Roles: []string{"admin"}}}),
+	)
+	return authenticator, private
+}
+
+func signToken(t *testing.T, key ed25519.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "book-my-seat-test",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "john@example.com",
+		"roles": []string{"passenger"},
+	}
+}
+
+func incoming(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestAuthenticateBearerToken(t *testing.T) {
+	authenticator, key := testAuthenticator(t)
+
+	identity, err := authenticator.Authenticate(incoming(auth.AuthorizationHeader, "Bearer "+signToken(t, key, "test", validClaims())))
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if identity.Subject != "user-1" || identity.Email != "john@example.com" || identity.Method != "jwt" {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+	if len(identity.Roles) != 1 || identity.Roles[0] != "passenger" {
+		t.Errorf("Expected roles [passenger], got %v", identity.Roles)
+	}
+}
+
+func TestAuthenticateRejectsInvalidTokens(t *testing.T) {
+	authenticator, key := testAuthenticator(t)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	noExpiry := validClaims()
+	delete(noExpiry, "exp")
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "someone-else"
+	noSubject := validClaims()
+	delete(noSubject, "sub")
+
+	tests := map[string]string{
+		"expired":       "Bearer " + signToken(t, key, "test", expired),
+		"no expiry":     "Bearer " + signToken(t, key, "test", noExpiry),
+		"wrong issuer":  "Bearer " + signToken(t, key, "test", wrongIssuer),
+		"no subject":    "Bearer " + signToken(t, key, "test", noSubject),
+		"wrong key":     "Bearer " + signToken(t, otherKey, "test", validClaims()),
+		"unknown kid":   "Bearer " + signToken(t, key, "other", validClaims()),
+		"garbage":       "Bearer not-a-jwt",
+		"wrong scheme":  "Basic dXNlcjpwYXNz",
+		"missing token": "Bearer",
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(incoming(auth.AuthorizationHeader, header))
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("Expected Unauthenticated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRejectsUnsignedTokens(t *testing.T) {
+	authenticator, _ := testAuthenticator(t)
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+	token.Header["kid"] = "test"
+	unsigned, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("Failed to build token: %v", err)
+	}
+	if _, err := authenticator.Authenticate(incoming(auth.AuthorizationHeader, "Bearer "+unsigned)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for an alg=none token, got %v", err)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	authenticator, _ := testAuthenticator(t)
+
+	identity, err := authenticator.Authenticate(incoming(auth.APIKeyHeader, testAPIKey))
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if identity.Subject != "ops" || identity.Method != "api-key" {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+
+	if _, err := authenticator.Authenticate(incoming(auth.APIKeyHeader, "wrong")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for a wrong API key, got %v", err)
+	}
+}
+
+func TestAuthenticateMissingCredentials(t *testing.T) {
+	authenticator, _ := testAuthenticator(t)
+	if _, err := authenticator.Authenticate(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without metadata, got %v", err)
+	}
+}
+
+func TestParseKeySetRejectsInvalidKeys(t *testing.T) {
+	tests := map[string]string{
+		"empty":            `{"keys": []}`,
+		"unknown type":     `{"keys": [{"kty": "XYZ", "kid": "a"}]}`,
+		"bad curve":        `{"keys": [{"kty": "OKP", "crv": "X25519", "kid": "a", "x": "AAAA"}]}`,
+		"mismatched alg":   `{"keys": [{"kty": "oct", "kid": "a", "k": "c2VjcmV0", "alg": "RS256"}]}`,
+		"duplicate key ID": `{"keys": [{"kty": "oct", "kid": "a", "k": "c2VjcmV0"}, {"kty": "oct", "kid": "a", "k": "c2VjcmV0"}]}`,
+		"not json":         `keys`,
+	}
+	for name, jwks := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := auth.ParseKeySet([]byte(jwks)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestInterceptorsPropagateIdentity(t *testing.T) {
+	authenticator, key := testAuthenticator(t)
+	listener := bufconn.Listen(1 << 20)
+	var seen *auth.Identity
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor(),
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				seen, _ = auth.FromContext(ctx)
+				return handler(ctx, req)
+			}),
+		grpc.ChainStreamInterceptor(authenticator.StreamInterceptor()),
+	)
+	pb.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceServer())
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := pb.NewBookingServiceClient(conn)
+
+	if _, err := client.GetReceipt(context.Background(), &pb.GetReceiptRequest{Email: "john@example.com"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated without credentials, got %v", err)
+	}
+	stream, err := client.ExportManifest(context.Background(), &pb.ExportManifestRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated for a stream without credentials, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), auth.AuthorizationHeader, "Bearer "+signToken(t, key, "test", validClaims()))
+	// john has no ticket, so NotFound shows that the call reached the handler.
+	if _, err := client.GetReceipt(ctx, &pb.GetReceiptRequest{Email: "john@example.com"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected the authenticated call to reach GetReceipt, got %v", err)
+	}
+	if seen == nil || seen.Subject != "user-1" {
+		t.Errorf("Expected the handler context to carry user-1, got %+v", seen)
+	}
+}
+
+func TestPublicServicesNeedNoCredentials(t *testing.T) {
+	authenticator := auth.NewAuthenticator(auth.WithPolicy(api.Policy), auth.WithPublicServices(readiness.HealthService))
+	gate := readiness.NewGate()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(authenticator.StreamInterceptor()),
+	)
+	pb.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceServer())
+	gate.Register(grpcServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Expected the health check to need no credentials, got %v", err)
+	}
+	if _, err := pb.NewBookingServiceClient(conn).ListTickets(context.Background(), &pb.ListTicketsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected booking calls to still need credentials, got %v", err)
+	}
+}
@@ -0,0 +1,547 @@
+// Package config assembles the server configuration from, in increasing order of
+// precedence, built-in defaults, a YAML or TOML file, BOOKMYSEAT_* environment
+// variables and command line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/internal/configfile"
+)
+
+// Config is the complete server configuration.
+type Config struct {
+	// Listen is the host:port the gRPC server listens on.
+	Listen string `yaml:"listen" toml:"listen"`
+	// Reflection registers the gRPC server reflection service.
+	Reflection bool `yaml:"reflection" toml:"reflection"`
+	// ShutdownTimeout bounds how long in-flight calls are drained on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// SigningKey is the PEM encoded Ed25519 key tickets are signed with.
+	SigningKey string    `yaml:"signing_key" toml:"signing_key"`
+	Gateway    Gateway   `yaml:"gateway" toml:"gateway"`
+	Metrics    Metrics   `yaml:"metrics" toml:"metrics"`
+	Tracing    Tracing   `yaml:"tracing" toml:"tracing"`
+	Storage    Storage   `yaml:"storage" toml:"storage"`
+	Events     Events    `yaml:"events" toml:"events"`
+	Notify     Notify    `yaml:"notify" toml:"notify"`
+	TLS        TLS       `yaml:"tls" toml:"tls"`
+	Auth       Auth      `yaml:"auth" toml:"auth"`
+	Layout     Layout    `yaml:"layout" toml:"layout"`
+	Limits     Limits    `yaml:"limits" toml:"limits"`
+	RateLimit  RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	Log        Log       `yaml:"log" toml:"log"`
+}
+
+// Gateway configures the HTTP/JSON and gRPC-Web API.
+type Gateway struct {
+	// Listen is the host:port the API is served on, over HTTPS when TLS is
+	// enabled. Empty disables it. Client certificates authenticate gRPC-Web
+	// calls there but not HTTP/JSON ones, which need a token or an API key.
+	Listen string `yaml:"listen" toml:"listen"`
+	// CORSOrigins lists the origins browsers may call the API from, such as
+	// https://app.example.com, separated by commas. "*" allows every origin.
+	CORSOrigins string `yaml:"cors_origins" toml:"cors_origins"`
+}
+
+// AllowedOrigins returns the configured CORS origins.
+func (g Gateway) AllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(g.CORSOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Metrics configures the Prometheus metrics endpoint.
+type Metrics struct {
+	// Listen is the host:port /metrics is served on over HTTP. Empty disables it.
+	Listen string `yaml:"listen" toml:"listen"`
+}
+
+// Tracing configures OpenTelemetry tracing.
+type Tracing struct {
+	// Exporter is "none", "stdout" or "otlp".
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// Insecure connects to the collector without TLS.
+	Insecure bool `yaml:"insecure" toml:"insecure"`
+	// SampleRatio is the fraction of new traces recorded.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// Storage selects where bookings are kept.
+type Storage struct {
+	// Backend is "memory" or "file".
+	Backend string `yaml:"backend" toml:"backend"`
+	// Path is the snapshot file of the file backend.
+	Path string `yaml:"path" toml:"path"`
+	// FlushInterval is how often changed bookings are saved.
+	FlushInterval time.Duration `yaml:"flush_interval" toml:"flush_interval"`
+}
+
+// Events configures the delivery of booking events to webhooks.
+type Events struct {
+	// Webhooks is a JSON file listing the webhooks. Empty disables events.
+	Webhooks string `yaml:"webhooks" toml:"webhooks"`
+	// MaxAttempts is the number of failed attempts after which a delivery is
+	// dead-lettered.
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// InitialBackoff is the wait after the first failed attempt. It doubles
+	// after every further failure, up to MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// Notify configures the notifications sent to passengers.
+type Notify struct {
+	// Channels lists the channels notifications are sent on, separated by
+	// commas: smtp, sms and log. Empty disables notifications.
+	Channels string `yaml:"channels" toml:"channels"`
+	// Templates is a directory of templates replacing the built-in ones.
+	Templates string `yaml:"templates" toml:"templates"`
+	// SMTPAddr is the host:port of the mail server and SMTPFrom the sender.
+	SMTPAddr     string `yaml:"smtp_addr" toml:"smtp_addr"`
+	SMTPFrom     string `yaml:"smtp_from" toml:"smtp_from"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password"`
+	// SMSURL is the endpoint of the SMS provider and SMSToken its bearer token.
+	SMSURL   string `yaml:"sms_url" toml:"sms_url"`
+	SMSToken string `yaml:"sms_token" toml:"sms_token"`
+	// MaxAttempts, InitialBackoff and MaxBackoff decide when failed sends are
+	// retried, as for events.
+	MaxAttempts    int           `yaml:"max_attempts" toml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// ChannelNames returns the configured notification channels.
+func (n Notify) ChannelNames() []string {
+	var names []string
+	for _, name := range strings.Split(n.Channels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// TLS configures transport security. TLS is enabled when Cert is set.
+type TLS struct {
+	Cert              string `yaml:"cert" toml:"cert"`
+	Key               string `yaml:"key" toml:"key"`
+	ClientCA          string `yaml:"client_ca" toml:"client_ca"`
+	RequireClientCert bool   `yaml:"require_client_cert" toml:"require_client_cert"`
+}
+
+// Auth configures how callers authenticate.
+type Auth struct {
+	JWKS           string `yaml:"jwks" toml:"jwks"`
+	JWTIssuer      string `yaml:"jwt_issuer" toml:"jwt_issuer"`
+	JWTAudience    string `yaml:"jwt_audience" toml:"jwt_audience"`
+	APIKeys        string `yaml:"api_keys" toml:"api_keys"`
+	AllowAnonymous bool   `yaml:"insecure_allow_anonymous" toml:"insecure_allow_anonymous"`
+}
+
+// Layout describes the train being booked.
+type Layout struct {
+	From            string `yaml:"from" toml:"from"`
+	To              string `yaml:"to" toml:"to"`
+	SeatsPerSection int    `yaml:"seats_per_section" toml:"seats_per_section"`
+}
+
+// Limits bound the work a single request can ask for.
+type Limits struct {
+	MaxImportRows       int `yaml:"max_import_rows" toml:"max_import_rows"`
+	MaxPageSize         int `yaml:"max_page_size" toml:"max_page_size"`
+	MaxRecvMessageBytes int `yaml:"max_recv_message_bytes" toml:"max_recv_message_bytes"`
+}
+
+// RateLimit bounds how often each caller, told apart by identity or IP address,
+// may call each method, and how many tickets it may hold. A rate is
+// written "<calls>/<s|m|h>", optionally followed by ":<burst>", the number of
+// calls allowed at once, e.g. "10/m:5". The burst defaults to the number of
+// calls, rounded up.
+type RateLimit struct {
+	// Default is the rate of every method without one of its own. Empty leaves
+	// them unlimited.
+	Default string `yaml:"default" toml:"default"`
+	// Methods lists method=rate pairs, separated by commas. Methods are named
+	// bare, e.g. PurchaseTicket, to match them in every version of the API, or
+	// in full, e.g. /BookingService.BookingService/PurchaseTicket.
+	Methods string `yaml:"methods" toml:"methods"`
+	// MaxTicketsPerCaller bounds the tickets a caller without a staff role may
+	// hold on the departure at once, whoever the passengers. 0 leaves it
+	// unbounded.
+	MaxTicketsPerCaller int `yaml:"max_tickets_per_caller" toml:"max_tickets_per_caller"`
+}
+
+// Rate is a parsed rate limit.
+type Rate struct {
+	// PerSecond is the number of calls allowed per second on average.
+	PerSecond float64
+	// Burst is the number of calls allowed at once.
+	Burst int
+}
+
+// ParseRate parses a rate written "<calls>/<s|m|h>[:<burst>]".
+func ParseRate(text string) (Rate, error) {
+	spec, burstText, hasBurst := strings.Cut(strings.TrimSpace(text), ":")
+	callsText, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("%q is not a rate such as 10/m or 10/m:5", text)
+	}
+	calls, err := strconv.ParseFloat(callsText, 64)
+	if err != nil || calls <= 0 || math.IsInf(calls, 0) {
+		return Rate{}, fmt.Errorf("%q: the number of calls must be positive", text)
+	}
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return Rate{}, fmt.Errorf("%q: the unit must be s, m or h", text)
+	}
+	rate := Rate{PerSecond: calls / per.Seconds(), Burst: int(math.Ceil(calls))}
+	if hasBurst {
+		if rate.Burst, err = strconv.Atoi(burstText); err != nil || rate.Burst < 1 {
+			return Rate{}, fmt.Errorf("%q: the burst must be a positive integer", text)
+		}
+	}
+	return rate, nil
+}
+
+// DefaultRate returns the configured default rate, or nil if there is none.
+func (r RateLimit) DefaultRate() (*Rate, error) {
+	if strings.TrimSpace(r.Default) == "" {
+		return nil, nil
+	}
+	rate, err := ParseRate(r.Default)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// MethodRates returns the configured rates by method.
+func (r RateLimit) MethodRates() (map[string]Rate, error) {
+	rates := make(map[string]Rate)
+	var errs []error
+	for _, pair := range strings.Split(r.Methods, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		method, text, ok := strings.Cut(pair, "=")
+		method = strings.TrimSpace(method)
+		if !ok || method == "" {
+			errs = append(errs, fmt.Errorf("%q is not a method=rate pair", pair))
+			continue
+		}
+		if _, seen := rates[method]; seen {
+			errs = append(errs, fmt.Errorf("%s is listed twice", method))
+			continue
+		}
+		rate, err := ParseRate(text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", method, err))
+			continue
+		}
+		rates[method] = rate
+	}
+	return rates, errors.Join(errs...)
+}
+
+// Log configures the server's log output.
+type Log struct {
+	// Level is "debug", "info", "warn" or "error".
+	Level string `yaml:"level" toml:"level"`
+	// Format is "text" or "json".
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Default returns the configuration used when nothing is configured.
+func Default() Config {
+	return Config{
+		Listen:          "0.0.0.0:50051",
+		ShutdownTimeout: 30 * time.Second,
+		Gateway:         Gateway{Listen: "0.0.0.0:8080"},
+		Metrics:         Metrics{Listen: "0.0.0.0:9090"},
+		Tracing:         Tracing{Exporter: "none", Endpoint: "localhost:4317", SampleRatio: 1},
+		Storage:         Storage{Backend: "memory", FlushInterval: 5 * time.Second},
+		Events:          Events{MaxAttempts: 10, InitialBackoff: 5 * time.Second, MaxBackoff: 15 * time.Minute},
+		Notify:          Notify{MaxAttempts: 5, InitialBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute},
+		Layout:          Layout{From: "London", To: "France", SeatsPerSection: 50},
+		Limits:          Limits{MaxImportRows: 10000, MaxPageSize: 100, MaxRecvMessageBytes: 4 << 20},
+		RateLimit:       RateLimit{Methods: "PurchaseTicket=5/m,CreateTicket=5/m"},
+		Log:             Log{Level: "info", Format: "text"},
+	}
+}
+
+// flagSet binds a flag to every setting of c.
+func (c *Config) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&c.Listen, "listen", c.Listen, "host:port to serve gRPC on")
+	fs.BoolVar(&c.Reflection, "reflection", c.Reflection, "serve gRPC reflection, without authentication, so tools such as grpcurl can discover the API")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "how long in-flight calls are drained on SIGINT or SIGTERM before being cut off")
+	fs.StringVar(&c.SigningKey, "signing-key", c.SigningKey, "PEM encoded Ed25519 private key used to sign tickets")
+	fs.StringVar(&c.Gateway.Listen, "gateway-listen", c.Gateway.Listen, "host:port to serve the HTTP/JSON and gRPC-Web API and its OpenAPI document on; empty disables it")
+	fs.StringVar(&c.Gateway.CORSOrigins, "gateway-cors-origins", c.Gateway.CORSOrigins, "comma separated origins browsers may call the gateway from, e.g. https://app.example.com; * allows every origin")
+	fs.StringVar(&c.Metrics.Listen, "metrics-listen", c.Metrics.Listen, "host:port to serve Prometheus metrics on at /metrics; empty disables them")
+	fs.StringVar(&c.Tracing.Exporter, "tracing-exporter", c.Tracing.Exporter, "where spans are exported: none, stdout or otlp")
+	fs.StringVar(&c.Tracing.Endpoint, "tracing-endpoint", c.Tracing.Endpoint, "host:port of the OTLP gRPC collector")
+	fs.BoolVar(&c.Tracing.Insecure, "tracing-insecure", c.Tracing.Insecure, "connect to the OTLP collector without TLS")
+	fs.Float64Var(&c.Tracing.SampleRatio, "tracing-sample-ratio", c.Tracing.SampleRatio, "fraction of new traces recorded, between 0 and 1")
+	fs.StringVar(&c.Storage.Backend, "storage-backend", c.Storage.Backend, "where bookings are kept: memory or file")
+	fs.StringVar(&c.Storage.Path, "storage-path", c.Storage.Path, "snapshot file of the file storage backend")
+	fs.DurationVar(&c.Storage.FlushInterval, "storage-flush-interval", c.Storage.FlushInterval, "how often changed bookings are saved")
+	fs.StringVar(&c.Events.Webhooks, "events-webhooks", c.Events.Webhooks, "JSON file listing the webhooks booking events are delivered to")
+	fs.IntVar(&c.Events.MaxAttempts, "events-max-attempts", c.Events.MaxAttempts, "failed attempts after which an event delivery is dead-lettered")
+	fs.DurationVar(&c.Events.InitialBackoff, "events-initial-backoff", c.Events.InitialBackoff, "wait after the first failed event delivery, doubling after every further failure")
+	fs.DurationVar(&c.Events.MaxBackoff, "events-max-backoff", c.Events.MaxBackoff, "longest wait between event delivery attempts")
+	fs.StringVar(&c.Notify.Channels, "notify-channels", c.Notify.Channels, "comma separated channels passengers are notified on: smtp, sms and log; empty disables notifications")
+	fs.StringVar(&c.Notify.Templates, "notify-templates", c.Notify.Templates, "directory of notification templates, one subdirectory per locale, replacing the built-in ones")
+	fs.StringVar(&c.Notify.SMTPAddr, "notify-smtp-addr", c.Notify.SMTPAddr, "host:port of the mail server of the smtp channel")
+	fs.StringVar(&c.Notify.SMTPFrom, "notify-smtp-from", c.Notify.SMTPFrom, "sender address of notification emails")
+	fs.StringVar(&c.Notify.SMTPUsername, "notify-smtp-username", c.Notify.SMTPUsername, "user name the smtp channel authenticates with")
+	fs.StringVar(&c.Notify.SMTPPassword, "notify-smtp-password", c.Notify.SMTPPassword, "password of -notify-smtp-username; prefer setting "+configfile.EnvName("notify-smtp-password"))
+	fs.StringVar(&c.Notify.SMSURL, "notify-sms-url", c.Notify.SMSURL, "URL of the SMS provider the sms channel posts messages to")
+	fs.StringVar(&c.Notify.SMSToken, "notify-sms-token", c.Notify.SMSToken, "bearer token of the SMS provider; prefer setting "+configfile.EnvName("notify-sms-token"))
+	fs.IntVar(&c.Notify.MaxAttempts, "notify-max-attempts", c.Notify.MaxAttempts, "failed attempts after which a notification is given up on")
+	fs.DurationVar(&c.Notify.InitialBackoff, "notify-initial-backoff", c.Notify.InitialBackoff, "wait after the first failed notification, doubling after every further failure")
+	fs.DurationVar(&c.Notify.MaxBackoff, "notify-max-backoff", c.Notify.MaxBackoff, "longest wait between notification attempts")
+	fs.StringVar(&c.TLS.Cert, "tls-cert", c.TLS.Cert, "PEM encoded server certificate chain; enables TLS")
+	fs.StringVar(&c.TLS.Key, "tls-key", c.TLS.Key, "PEM encoded private key of -tls-cert")
+	fs.StringVar(&c.TLS.ClientCA, "tls-client-ca", c.TLS.ClientCA, "CAs that client certificates are verified against; verified certificates authenticate their holder")
+	fs.BoolVar(&c.TLS.RequireClientCert, "tls-require-client-cert", c.TLS.RequireClientCert, "reject connections without a valid client certificate")
+	fs.StringVar(&c.Auth.JWKS, "jwks", c.Auth.JWKS, "JSON Web Key Set whose keys may sign bearer tokens")
+	fs.StringVar(&c.Auth.JWTIssuer, "jwt-issuer", c.Auth.JWTIssuer, "required \"iss\" claim of bearer tokens")
+	fs.StringVar(&c.Auth.JWTAudience, "jwt-audience", c.Auth.JWTAudience, "required \"aud\" claim of bearer tokens")
+	fs.StringVar(&c.Auth.APIKeys, "api-keys", c.Auth.APIKeys, "JSON file listing the accepted API keys by SHA-256 hash")
+	fs.BoolVar(&c.Auth.AllowAnonymous, "insecure-allow-anonymous", c.Auth.AllowAnonymous, "serve requests without authentication (development only)")
+	fs.StringVar(&c.Layout.From, "layout-from", c.Layout.From, "station the train departs from")
+	fs.StringVar(&c.Layout.To, "layout-to", c.Layout.To, "station the train arrives at")
+	fs.IntVar(&c.Layout.SeatsPerSection, "layout-seats-per-section", c.Layout.SeatsPerSection, "number of seats in each section")
+	fs.IntVar(&c.Limits.MaxImportRows, "limits-max-import-rows", c.Limits.MaxImportRows, "maximum rows of one ImportBookings call")
+	fs.IntVar(&c.Limits.MaxPageSize, "limits-max-page-size", c.Limits.MaxPageSize, "maximum page size of list and search calls")
+	fs.IntVar(&c.Limits.MaxRecvMessageBytes, "limits-max-recv-message-bytes", c.Limits.MaxRecvMessageBytes, "maximum size of a received gRPC message")
+	fs.StringVar(&c.RateLimit.Default, "rate-limit-default", c.RateLimit.Default, "rate each caller may call methods without a rate of their own at, e.g. 100/s or 600/m:50; empty leaves them unlimited")
+	fs.StringVar(&c.RateLimit.Methods, "rate-limit-methods", c.RateLimit.Methods, "comma separated method=rate pairs, e.g. PurchaseTicket=5/m:2, limiting how often each caller may call the method")
+	fs.IntVar(&c.RateLimit.MaxTicketsPerCaller, "rate-limit-max-tickets-per-caller", c.RateLimit.MaxTicketsPerCaller, "most tickets a caller without a staff role may hold at once, whoever the passengers; 0 leaves it unbounded")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "minimum level logged: debug, info, warn or error")
+	fs.StringVar(&c.Log.Format, "log-format", c.Log.Format, "log output format: text or json")
+	return fs
+}
+
+// Load builds the configuration from args (without the program name), the
+// environment as seen through getenv and the file named by the -config flag or
+// the BOOKMYSEAT_CONFIG variable. The result is validated; every problem found
+// is reported in the returned error.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	c := Default()
+	rest, err := configfile.Load(c.flagSet("server"), &c, func() { c = Default() }, configfile.EnvName("config"), args, getenv)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", rest)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate reports every inconsistent or out of range setting.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if err := checkAddress(c.Listen); err != nil {
+		invalid("listen: %v", err)
+	}
+	if c.Metrics.Listen != "" {
+		if err := checkAddress(c.Metrics.Listen); err != nil {
+			invalid("metrics.listen: %v", err)
+		} else if c.Metrics.Listen == c.Listen && !strings.HasSuffix(c.Listen, ":0") {
+			invalid("metrics.listen must differ from listen")
+		}
+	}
+	if c.Gateway.Listen != "" {
+		if err := checkAddress(c.Gateway.Listen); err != nil {
+			invalid("gateway.listen: %v", err)
+		} else if (c.Gateway.Listen == c.Listen || c.Gateway.Listen == c.Metrics.Listen) && !strings.HasSuffix(c.Gateway.Listen, ":0") {
+			invalid("gateway.listen must differ from listen and metrics.listen")
+		}
+	}
+	for _, origin := range c.Gateway.AllowedOrigins() {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			invalid("gateway.cors_origins: %q is not a scheme://host[:port] origin", origin)
+		}
+	}
+	if c.Gateway.Listen == "" && c.Gateway.CORSOrigins != "" {
+		invalid("gateway.cors_origins needs gateway.listen")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if err := checkAddress(c.Tracing.Endpoint); err != nil {
+			invalid("tracing.endpoint: %v", err)
+		}
+	default:
+		invalid("tracing.exporter must be none, stdout or otlp, not %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio must be between 0 and 1")
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		invalid("shutdown_timeout must be positive")
+	}
+
+	switch c.Storage.Backend {
+	case "memory":
+	case "file":
+		if c.Storage.Path == "" {
+			invalid("storage.path is required by the file backend")
+		}
+	default:
+		invalid("storage.backend must be memory or file, not %q", c.Storage.Backend)
+	}
+	if c.Storage.FlushInterval <= 0 {
+		invalid("storage.flush_interval must be positive")
+	}
+
+	if c.Events.MaxAttempts < 1 {
+		invalid("events.max_attempts must be positive")
+	}
+	if c.Events.InitialBackoff <= 0 {
+		invalid("events.initial_backoff must be positive")
+	} else if c.Events.MaxBackoff < c.Events.InitialBackoff {
+		invalid("events.max_backoff must be at least events.initial_backoff")
+	}
+
+	seen := make(map[string]bool)
+	for _, channel := range c.Notify.ChannelNames() {
+		if seen[channel] {
+			invalid("notify.channels lists %s twice", channel)
+		}
+		seen[channel] = true
+		switch channel {
+		case "log":
+		case "smtp":
+			if err := checkAddress(c.Notify.SMTPAddr); err != nil {
+				invalid("notify.smtp_addr: %v", err)
+			}
+			if _, err := mail.ParseAddress(c.Notify.SMTPFrom); err != nil {
+				invalid("notify.smtp_from: %v", err)
+			}
+		case "sms":
+			if u, err := url.Parse(c.Notify.SMSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				invalid("notify.sms_url must be an http or https URL")
+			}
+		default:
+			invalid("notify.channels may only list smtp, sms and log, not %q", channel)
+		}
+	}
+	if c.Notify.MaxAttempts < 1 {
+		invalid("notify.max_attempts must be positive")
+	}
+	if c.Notify.InitialBackoff <= 0 {
+		invalid("notify.initial_backoff must be positive")
+	} else if c.Notify.MaxBackoff < c.Notify.InitialBackoff {
+		invalid("notify.max_backoff must be at least notify.initial_backoff")
+	}
+
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		invalid("tls.cert and tls.key must be set together")
+	}
+	if c.TLS.Cert == "" && (c.TLS.ClientCA != "" || c.TLS.RequireClientCert) {
+		invalid("tls.client_ca and tls.require_client_cert need tls.cert and tls.key")
+	}
+	if c.TLS.RequireClientCert && c.TLS.ClientCA == "" {
+		invalid("tls.require_client_cert needs tls.client_ca")
+	}
+
+	if c.Auth.JWKS == "" && c.Auth.APIKeys == "" && c.TLS.ClientCA == "" && !c.Auth.AllowAnonymous {
+		invalid("no authentication configured: set auth.jwks, auth.api_keys or tls.client_ca, or auth.insecure_allow_anonymous for development")
+	}
+	if c.Auth.JWKS == "" && (c.Auth.JWTIssuer != "" || c.Auth.JWTAudience != "") {
+		invalid("auth.jwt_issuer and auth.jwt_audience need auth.jwks")
+	}
+
+	if strings.TrimSpace(c.Layout.From) == "" || strings.TrimSpace(c.Layout.To) == "" {
+		invalid("layout.from and layout.to are required")
+	} else if strings.EqualFold(c.Layout.From, c.Layout.To) {
+		invalid("layout.from and layout.to must differ")
+	}
+	if c.Layout.SeatsPerSection < 1 || c.Layout.SeatsPerSection > 10000 {
+		invalid("layout.seats_per_section must be between 1 and 10000")
+	}
+
+	if c.Limits.MaxImportRows < 1 {
+		invalid("limits.max_import_rows must be positive")
+	}
+	if c.Limits.MaxPageSize < 1 {
+		invalid("limits.max_page_size must be positive")
+	}
+	if c.Limits.MaxRecvMessageBytes < 1024 {
+		invalid("limits.max_recv_message_bytes must be at least 1024")
+	}
+
+	if _, err := c.RateLimit.DefaultRate(); err != nil {
+		invalid("rate_limit.default: %v", err)
+	}
+	if _, err := c.RateLimit.MethodRates(); err != nil {
+		invalid("rate_limit.methods: %v", err)
+	}
+	if c.RateLimit.MaxTicketsPerCaller < 0 {
+		invalid("rate_limit.max_tickets_per_caller cannot be negative")
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log.level must be debug, info, warn or error, not %q", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		invalid("log.format must be text or json, not %q", c.Log.Format)
+	}
+	return errors.Join(errs...)
+}
+
+// checkAddress reports whether address is a host:port that can be listened on.
+// Port 0 is accepted and picks a free port.
+func checkAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/config"
+)
+
+func environment(vars map[string]string) func(string) string {
+	return func(name string) string {
+		return vars[name]
+	}
+}
+
+func writeConfig(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Writing %s failed: %v", name, err)
+	}
+	return path
+}
+
+func TestDefaults(t *testing.T) {
+	cfg, err := config.Load([]string{"-insecure-allow-anonymous"}, environment(nil))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := config.Default()
+	want.Auth.AllowAnonymous = true
+	if *cfg != want {
+		t.Errorf("Expected the defaults %+v, got %+v", want, *cfg)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	files := map[string]string{
+		"server.yaml": `
+listen: 127.0.0.1:6000
+storage:
+  backend: file
+  path: /var/lib/bookings.json
+  flush_interval: 30s
+auth:
+  api_keys: /etc/keys.json
+layout:
+  from: Paris
+  seats_per_section: 20
+log:
+  level: debug
+`,
+		"server.toml": `
+listen = "127.0.0.1:6000"
+
+[storage]
+backend = "file"
+path = "/var/lib/bookings.json"
+flush_interval = "30s"
+
+[auth]
+api_keys = "/etc/keys.json"
+
+[layout]
+from = "Paris"
+seats_per_section = 20
+
+[log]
+level = "debug"
+`,
+	}
+	for name, contents := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfig(t, name, contents)
+			env := environment(map[string]string{
+				"BOOKMYSEAT_CONFIG":                   path,
+				"BOOKMYSEAT_LAYOUT_SEATS_PER_SECTION": "30",
+				"BOOKMYSEAT_LOG_LEVEL":                "warn",
+			})
+			cfg, err := config.Load([]string{"-log-level", "error"}, env)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if cfg.Listen != "127.0.0.1:6000" || cfg.Storage.Backend != "file" || cfg.Storage.FlushInterval != 30*time.Second {
+				t.Errorf("Expected the file settings to apply, got %+v", cfg)
+			}
+			if cfg.Layout.From != "Paris" || cfg.Layout.To != "France" {
+				t.Errorf("Expected the file to override only layout.from, got %+v", cfg.Layout)
+			}
+			if cfg.Layout.SeatsPerSection != 30 {
+				t.Errorf("Expected the environment to override the file, got %d seats", cfg.Layout.SeatsPerSection)
+			}
+			if cfg.Log.Level != "error" {
+				t.Errorf("Expected the flag to override the environment, got level %q", cfg.Log.Level)
+			}
+		})
+	}
+}
+
+func TestConfigFlagOverridesEnvironment(t *testing.T) {
+	fromEnv := writeConfig(t, "env.yaml", "listen: 127.0.0.1:7000\nauth:\n  insecure_allow_anonymous: true\n")
+	fromFlag := writeConfig(t, "flag.yaml", "listen: 127.0.0.1:8000\nauth:\n  insecure_allow_anonymous: true\n")
+	cfg, err := config.Load([]string{"-config", fromFlag}, environment(map[string]string{"BOOKMYSEAT_CONFIG": fromEnv}))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Listen != "127.0.0.1:8000" {
+		t.Errorf("Expected the -config file to be used, got listen %q", cfg.Listen)
+	}
+}
+
+func TestUnknownSettings(t *testing.T) {
+	for name, contents := range map[string]string{
+		"server.yaml": "listen: 127.0.0.1:6000\nlisten_port: 6000\n",
+		"server.toml": "listen = \"127.0.0.1:6000\"\nlisten_port = 6000\n",
+		"server.ini":  "listen=127.0.0.1:6000\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := config.Load([]string{"-config", writeConfig(t, name, contents)}, environment(nil)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestValidationReportsEveryProblem(t *testing.T) {
+	env := environment(map[string]string{
+		"BOOKMYSEAT_STORAGE_BACKEND":      "file",
+		"BOOKMYSEAT_LOG_FORMAT":           "xml",
+		"BOOKMYSEAT_TLS_CERT":             "/etc/server.pem",
+		"BOOKMYSEAT_LIMITS_MAX_PAGE_SIZE": "0",
+		"BOOKMYSEAT_METRICS_LISTEN":       "0.0.0.0:http",
+		"BOOKMYSEAT_GATEWAY_LISTEN":       "8080",
+		"BOOKMYSEAT_GATEWAY_CORS_ORIGINS": "https://app.example.com, app.example.com",
+		"BOOKMYSEAT_TRACING_EXPORTER":     "jaeger",
+		"BOOKMYSEAT_EVENTS_MAX_BACKOFF":   "1s",
+		"BOOKMYSEAT_NOTIFY_CHANNELS":      "smtp,pigeon",
+		"BOOKMYSEAT_RATE_LIMIT_METHODS":   "PurchaseTicket=5/m,ListTickets=often",
+	})
+	_, err := config.Load([]string{"-listen", "localhost", "-layout-to", "london"}, env)
+	if err == nil {
+		t.Fatalf("Expected validation to fail")
+	}
+	for _, problem := range []string{"listen", "storage.path", "log.format", "tls.cert and tls.key", "max_page_size", "no authentication", "must differ", "metrics.listen", "gateway.listen", `"app.example.com"`, "tracing.exporter", "events.max_backoff", "notify.smtp_addr", "notify.smtp_from", "pigeon", "ListTickets: \"often\""} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the error to mention %q, got:\n%v", problem, err)
+		}
+	}
+}
+
+func TestInvalidEnvironmentValue(t *testing.T) {
+	_, err := config.Load(nil, environment(map[string]string{"BOOKMYSEAT_STORAGE_FLUSH_INTERVAL": "often"}))
+	if err == nil || !strings.Contains(err.Error(), "BOOKMYSEAT_STORAGE_FLUSH_INTERVAL") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for text, expected := range map[string]config.Rate{
+		"5/s":    {PerSecond: 5, Burst: 5},
+		"30/m":   {PerSecond: 0.5, Burst: 30},
+		"30/m:2": {PerSecond: 0.5, Burst: 2},
+		"0.5/s":  {PerSecond: 0.5, Burst: 1},
+		"3600/h": {PerSecond: 1, Burst: 3600},
+	} {
+		if got, err := config.ParseRate(text); err != nil || got != expected {
+			t.Errorf("%s: expected %+v, got %+v and %v", text, expected, got, err)
+		}
+	}
+	for _, text := range []string{"", "5", "5/d", "0/s", "-1/s", "five/s", "5/s:0", "5/s:x"} {
+		if _, err := config.ParseRate(text); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides when failed deliveries are attempted again.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts after which a delivery is
+	// dead-lettered.
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failure. It doubles after
+	// every further failure, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy gives a webhook about an hour to recover.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 10, InitialBackoff: 5 * time.Second, MaxBackoff: 15 * time.Minute}
+
+// Backoff returns the wait after the given number of failed attempts.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < attempts && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// Dispatcher delivers the deliveries of an Outbox to their webhooks.
+type Dispatcher struct {
+	outbox *Outbox
+	client *http.Client
+	retry  RetryPolicy
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithHTTPClient sets the client webhook requests are sent with.
+func WithHTTPClient(client *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithRetryPolicy sets when failed deliveries are attempted again.
+func WithRetryPolicy(retry RetryPolicy) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.retry = retry
+	}
+}
+
+// WithClock sets the source of the current time, for tests.
+func WithClock(now func() time.Time) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.now = now
+	}
+}
+
+// WithLogger sets where delivery failures are logged.
+func WithLogger(logger *slog.Logger) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
+
+// NewDispatcher returns a dispatcher for outbox.
+func NewDispatcher(outbox *Outbox, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		outbox: outbox,
+		client: &http.Client{Timeout: 10 * time.Second},
+		retry:  DefaultRetryPolicy,
+		now:    time.Now,
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Run delivers events as they are published, and retries failed deliveries,
+// until ctx is cancelled. Deliveries still pending then stay in the outbox.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		next := d.DeliverDue(ctx)
+		wait := time.Minute
+		if !next.IsZero() {
+			wait = next.Sub(d.now())
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.outbox.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// DeliverDue attempts every delivery that is due, concurrently, and returns the
+// time of the next attempt, zero if nothing is left to retry.
+func (d *Dispatcher) DeliverDue(ctx context.Context) time.Time {
+	due, _ := d.outbox.due(d.now())
+	var wg sync.WaitGroup
+	for _, delivery := range due {
+		wg.Add(1)
+		go func(delivery *Delivery) {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+	_, next := d.outbox.due(d.now())
+	return next
+}
+
+// attempt sends delivery once and records the outcome in the outbox.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) {
+	webhook, ok := d.outbox.webhooks[delivery.Webhook]
+	if !ok {
+		return
+	}
+	permanent, err := d.send(ctx, webhook, delivery.Event)
+	if ctx.Err() != nil {
+		// Shutting down: leave the delivery as it was.
+		return
+	}
+	d.outbox.update(delivery, func(pending *Delivery) outcome {
+		if err == nil {
+			return delivered
+		}
+		pending.Attempts++
+		pending.LastError = err.Error()
+		if permanent || pending.Attempts >= d.retry.MaxAttempts {
+			d.logger.Error("giving up on webhook delivery",
+				"webhook", pending.Webhook, "event_id", pending.Event.ID, "event_type", pending.Event.Type,
+				"attempts", pending.Attempts, "error", err)
+			return deadLettered
+		}
+		pending.NextAttempt = d.now().Add(d.retry.Backoff(pending.Attempts))
+		d.logger.Warn("webhook delivery failed, will retry",
+			"webhook", pending.Webhook, "event_id", pending.Event.ID, "attempts", pending.Attempts,
+			"next_attempt", pending.NextAttempt, "error", err)
+		return retry
+	})
+}
+
+// send POSTs event to webhook. Failures that retrying cannot fix, such as the
+// webhook rejecting the request as malformed or unauthorized, are permanent.
+func (d *Dispatcher) send(ctx context.Context, webhook Webhook, event Event) (permanent bool, err error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return true, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, event.ID)
+	req.Header.Set(EventTypeHeader, string(event.Type))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return false, fmt.Errorf("webhook responded %s", resp.Status)
+	default:
+		return true, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+}
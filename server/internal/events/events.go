@@ -0,0 +1,148 @@
+// Package events publishes changes to the bookings to other systems. The
+// booking service adds events to an Outbox in the same critical section, and
+// the same snapshot, as the change itself, so an event is stored if and only if
+// its change is. A Dispatcher then delivers them to HTTP webhooks, signing each
+// request with the webhook's secret and retrying failed deliveries with
+// exponential backoff until they succeed or end up in the dead-letter list.
+//
+// Delivery is at least once: a delivery that succeeded just before a crash may
+// be repeated after the restart, so receivers should ignore event IDs they have
+// already seen.
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type names a kind of event.
+type Type string
+
+// Types of the events published by the booking service.
+const (
+	TicketPurchased Type = "TicketPurchased"
+	SeatChanged     Type = "SeatChanged"
+	TicketCancelled Type = "TicketCancelled"
+)
+
+// Event is a change to the bookings, as sent to webhooks.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       Type      `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	TicketID   string    `json:"ticket_id"`
+	// Data holds the ticket before and after the change, as
+	// {"before": ticket, "after": ticket}; either is null when the ticket did
+	// not exist.
+	Data json.RawMessage `json:"data"`
+}
+
+// Webhook is an HTTP endpoint events are POSTed to.
+type Webhook struct {
+	// Name identifies the webhook in deliveries and logs.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret is the HMAC key requests are signed with.
+	Secret string `json:"secret"`
+	// Types lists the events the webhook receives, every type when empty.
+	Types []Type `json:"types,omitempty"`
+}
+
+// subscribes reports whether w receives events of type t.
+func (w Webhook) subscribes(t Type) bool {
+	if len(w.Types) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Types {
+		if subscribed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadWebhooks reads a JSON array of Webhook entries from path.
+func LoadWebhooks(path string) ([]Webhook, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading webhooks: %v", err)
+	}
+	var webhooks []Webhook
+	if err := json.Unmarshal(raw, &webhooks); err != nil {
+		return nil, fmt.Errorf("parsing webhooks: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, webhook := range webhooks {
+		if webhook.Name == "" || webhook.URL == "" || webhook.Secret == "" {
+			return nil, fmt.Errorf("webhook entries need a name, a url and a secret")
+		}
+		if names[webhook.Name] {
+			return nil, fmt.Errorf("duplicate webhook %q", webhook.Name)
+		}
+		names[webhook.Name] = true
+		for _, t := range webhook.Types {
+			if t != TicketPurchased && t != SeatChanged && t != TicketCancelled {
+				return nil, fmt.Errorf("webhook %q subscribes to unknown event type %q", webhook.Name, t)
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+// Headers set on every webhook request.
+const (
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>", the HMAC
+	// being computed with the webhook secret over "<unix seconds>.<body>".
+	SignatureHeader = "X-BookMySeat-Signature"
+	EventIDHeader   = "X-BookMySeat-Event-Id"
+	EventTypeHeader = "X-BookMySeat-Event-Type"
+)
+
+// Sign returns the SignatureHeader value for body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	seconds := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + seconds + ",v1=" + hex.EncodeToString(mac(secret, seconds, body))
+}
+
+func mac(secret, seconds string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(seconds))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// VerifySignature checks a SignatureHeader value against body, rejecting
+// signatures made more than tolerance away from now so that captured requests
+// cannot be replayed later. Receivers written in Go can use it as is.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var seconds, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			seconds = value
+		case "v1":
+			signature = value
+		}
+	}
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || signature == "" {
+		return fmt.Errorf("malformed signature header")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("signature timestamp is outside the tolerance")
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, mac(secret, seconds, body)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
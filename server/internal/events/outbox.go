@@ -0,0 +1,166 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery is an event waiting to be delivered to one webhook, or given up on.
+type Delivery struct {
+	Event   Event  `json:"event"`
+	Webhook string `json:"webhook"`
+	// Attempts counts the failed attempts so far.
+	Attempts int `json:"attempts"`
+	// NextAttempt is when the delivery is due, zero for one not attempted yet.
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Outbox holds the deliveries that are still pending and those that were
+// given up on. It is safe for concurrent use.
+type Outbox struct {
+	webhooks map[string]Webhook
+	// notify is signalled when a delivery is added, to wake the dispatcher.
+	notify chan struct{}
+
+	mu      sync.Mutex
+	pending []*Delivery
+	dead    []*Delivery
+	changes uint64 // incremented by every change to pending or dead
+}
+
+// NewOutbox returns an outbox fanning events out to webhooks.
+func NewOutbox(webhooks []Webhook) *Outbox {
+	o := &Outbox{webhooks: make(map[string]Webhook), notify: make(chan struct{}, 1)}
+	for _, webhook := range webhooks {
+		o.webhooks[webhook.Name] = webhook
+	}
+	return o
+}
+
+// Publish queues event for every webhook subscribed to its type, filling in its
+// ID and time if they are unset. Events nobody subscribes to are dropped.
+func (o *Outbox) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	o.mu.Lock()
+	added := false
+	for name, webhook := range o.webhooks {
+		if webhook.subscribes(event.Type) {
+			o.pending = append(o.pending, &Delivery{Event: event, Webhook: name})
+			added = true
+		}
+	}
+	if added {
+		o.changes++
+	}
+	o.mu.Unlock()
+
+	if added {
+		select {
+		case o.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Restore replaces the outbox contents with those saved in a snapshot.
+// Deliveries to webhooks that are no longer configured are dead-lettered.
+func (o *Outbox) Restore(pending, dead []*Delivery) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending = nil
+	o.dead = append([]*Delivery(nil), dead...)
+	for _, delivery := range pending {
+		if _, ok := o.webhooks[delivery.Webhook]; !ok {
+			delivery.LastError = "webhook is no longer configured"
+			o.dead = append(o.dead, delivery)
+			continue
+		}
+		o.pending = append(o.pending, delivery)
+	}
+}
+
+// Snapshot returns copies of the pending and dead-lettered deliveries.
+func (o *Outbox) Snapshot() (pending, dead []*Delivery) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return copyDeliveries(o.pending), copyDeliveries(o.dead)
+}
+
+// Changes counts the changes made to the outbox since it was created, so that
+// callers can tell whether it needs saving again.
+func (o *Outbox) Changes() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.changes
+}
+
+// DeadLetters returns copies of the deliveries that were given up on.
+func (o *Outbox) DeadLetters() []*Delivery {
+	_, dead := o.Snapshot()
+	return dead
+}
+
+// due returns the pending deliveries whose next attempt is at or before now,
+// and the time of the earliest later attempt, zero if there is none.
+func (o *Outbox) due(now time.Time) ([]*Delivery, time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var due []*Delivery
+	var next time.Time
+	for _, delivery := range o.pending {
+		if !delivery.NextAttempt.After(now) {
+			copied := *delivery
+			due = append(due, &copied)
+		} else if next.IsZero() || delivery.NextAttempt.Before(next) {
+			next = delivery.NextAttempt
+		}
+	}
+	return due, next
+}
+
+// update applies change to the pending delivery of the same event and webhook
+// as delivery. change returns what should become of it.
+func (o *Outbox) update(delivery *Delivery, change func(*Delivery) outcome) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, pending := range o.pending {
+		if pending.Event.ID != delivery.Event.ID || pending.Webhook != delivery.Webhook {
+			continue
+		}
+		o.changes++
+		switch change(pending) {
+		case delivered:
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+		case deadLettered:
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+			o.dead = append(o.dead, pending)
+		}
+		return
+	}
+}
+
+type outcome int
+
+const (
+	retry outcome = iota
+	delivered
+	deadLettered
+)
+
+func copyDeliveries(deliveries []*Delivery) []*Delivery {
+	copies := make([]*Delivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		copied := *delivery
+		copies = append(copies, &copied)
+	}
+	return copies
+}
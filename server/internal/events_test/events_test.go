@@ -0,0 +1,306 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+)
+
+// receiver is a webhook endpoint recording the requests it accepts. It answers
+// with the queued statuses first, then 204.
+type receiver struct {
+	*httptest.Server
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []events.Event
+	failures []string
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	t.Helper()
+	r := &receiver{secret: secret, statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := events.VerifySignature(r.secret, req.Header.Get(events.SignatureHeader), body, 5*time.Minute, time.Now()); err != nil {
+		r.failures = append(r.failures, err.Error())
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		w.WriteHeader(status)
+		return
+	}
+	var event events.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		r.failures = append(r.failures, err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.Header.Get(events.EventIDHeader) != event.ID || req.Header.Get(events.EventTypeHeader) != string(event.Type) {
+		r.failures = append(r.failures, "event headers do not match the body")
+	}
+	r.received = append(r.received, event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *receiver) events(t *testing.T) []events.Event {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, failure := range r.failures {
+		t.Errorf("Receiver rejected a request: %s", failure)
+	}
+	return append([]events.Event(nil), r.received...)
+}
+
+// clock is a fake clock the tests move forward by hand.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func purchase(ticketID string) events.Event {
+	return events.Event{Type: events.TicketPurchased, TicketID: ticketID, Data: json.RawMessage(`{"before":null,"after":{"id":"` + ticketID + `"}}`)}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1700000000, 0)
+	header := events.Sign("s3cret", now, body)
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Errorf("Unexpected signature header %q", header)
+	}
+	if err := events.VerifySignature("s3cret", header, body, time.Minute, now.Add(30*time.Second)); err != nil {
+		t.Errorf("Expected the signature to verify, got %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"wrong secret":  func() error { return events.VerifySignature("other", header, body, time.Minute, now) },
+		"modified body": func() error { return events.VerifySignature("s3cret", header, []byte(`{"id":"2"}`), time.Minute, now) },
+		"replayed later": func() error {
+			return events.VerifySignature("s3cret", header, body, time.Minute, now.Add(2*time.Minute))
+		},
+		"malformed": func() error { return events.VerifySignature("s3cret", "v1=abc", body, time.Minute, now) },
+	} {
+		if check() == nil {
+			t.Errorf("%s: expected the signature to be rejected", name)
+		}
+	}
+}
+
+func TestDeliversSignedEventsToSubscribedWebhooks(t *testing.T) {
+	everything := newReceiver(t, "secret-1")
+	cancellations := newReceiver(t, "secret-2")
+	outbox := events.NewOutbox([]events.Webhook{
+		{Name: "crm", URL: everything.URL, Secret: "secret-1"},
+		{Name: "refunds", URL: cancellations.URL, Secret: "secret-2", Types: []events.Type{events.TicketCancelled}},
+	})
+	dispatcher := events.NewDispatcher(outbox)
+
+	outbox.Publish(purchase("ticket-1"))
+	outbox.Publish(events.Event{Type: events.TicketCancelled, TicketID: "ticket-1"})
+	if next := dispatcher.DeliverDue(context.Background()); !next.IsZero() {
+		t.Errorf("Expected nothing left to retry, next attempt at %v", next)
+	}
+
+	if received := everything.events(t); len(received) != 2 {
+		t.Errorf("Expected crm to receive both events, got %v", received)
+	}
+	received := cancellations.events(t)
+	if len(received) != 1 || received[0].Type != events.TicketCancelled || received[0].TicketID != "ticket-1" {
+		t.Fatalf("Expected refunds to receive only the cancellation, got %v", received)
+	}
+	if received[0].ID == "" || received[0].OccurredAt.IsZero() {
+		t.Errorf("Expected the event ID and time to be filled in, got %+v", received[0])
+	}
+	if pending, dead := outbox.Snapshot(); len(pending) != 0 || len(dead) != 0 {
+		t.Errorf("Expected the outbox to be empty, got %d pending and %d dead", len(pending), len(dead))
+	}
+}
+
+func TestRetriesWithBackoff(t *testing.T) {
+	webhook := newReceiver(t, "secret", http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	outbox := events.NewOutbox([]events.Webhook{{Name: "crm", URL: webhook.URL, Secret: "secret"}})
+	fake := &clock{now: time.Now()}
+	dispatcher := events.NewDispatcher(outbox,
+		events.WithClock(fake.Now),
+		events.WithRetryPolicy(events.RetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Second, MaxBackoff: 15 * time.Second}),
+	)
+
+	outbox.Publish(purchase("ticket-1"))
+	start := fake.Now()
+	if next := dispatcher.DeliverDue(context.Background()); !next.Equal(start.Add(10 * time.Second)) {
+		t.Errorf("Expected the first retry 10s later, got %v", next.Sub(start))
+	}
+	pending, _ := outbox.Snapshot()
+	if len(pending) != 1 || pending[0].Attempts != 1 || !strings.Contains(pending[0].LastError, "503") {
+		t.Fatalf("Expected one failed attempt to be recorded, got %+v", pending)
+	}
+
+	// Nothing is sent before the backoff has passed.
+	fake.Advance(5 * time.Second)
+	dispatcher.DeliverDue(context.Background())
+	if pending, _ := outbox.Snapshot(); pending[0].Attempts != 1 {
+		t.Errorf("Expected no attempt before the backoff passed, got %d attempts", pending[0].Attempts)
+	}
+
+	fake.Advance(5 * time.Second)
+	if next := dispatcher.DeliverDue(context.Background()); !next.Equal(fake.Now().Add(15 * time.Second)) {
+		t.Errorf("Expected the doubled backoff to be capped at 15s, got %v", next.Sub(fake.Now()))
+	}
+	fake.Advance(15 * time.Second)
+	if next := dispatcher.DeliverDue(context.Background()); !next.IsZero() {
+		t.Errorf("Expected the third attempt to succeed, next attempt at %v", next)
+	}
+	if received := webhook.events(t); len(received) != 1 || received[0].TicketID != "ticket-1" {
+		t.Errorf("Expected the event to be delivered once, got %v", received)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	failing := newReceiver(t, "secret", http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	rejecting := newReceiver(t, "secret", http.StatusGone)
+	outbox := events.NewOutbox([]events.Webhook{
+		{Name: "failing", URL: failing.URL, Secret: "secret"},
+		{Name: "rejecting", URL: rejecting.URL, Secret: "secret"},
+	})
+	fake := &clock{now: time.Now()}
+	dispatcher := events.NewDispatcher(outbox,
+		events.WithClock(fake.Now),
+		events.WithRetryPolicy(events.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Second}),
+	)
+
+	outbox.Publish(purchase("ticket-1"))
+	for i := 0; i < 3; i++ {
+		dispatcher.DeliverDue(context.Background())
+		fake.Advance(time.Second)
+	}
+
+	pending, dead := outbox.Snapshot()
+	if len(pending) != 0 {
+		t.Errorf("Expected nothing to be pending, got %+v", pending)
+	}
+	attempts := make(map[string]int)
+	for _, delivery := range dead {
+		attempts[delivery.Webhook] = delivery.Attempts
+		if delivery.Event.TicketID != "ticket-1" || delivery.LastError == "" {
+			t.Errorf("Unexpected dead letter %+v", delivery)
+		}
+	}
+	// 410 Gone cannot be fixed by retrying, 502 is retried until MaxAttempts.
+	if attempts["rejecting"] != 1 || attempts["failing"] != 3 || len(dead) != 2 {
+		t.Errorf("Expected both deliveries to be dead-lettered, got %+v", dead)
+	}
+}
+
+func TestStoppedDispatcherLeavesDeliveriesPending(t *testing.T) {
+	outbox := events.NewOutbox([]events.Webhook{{Name: "crm", URL: "http://127.0.0.1:1", Secret: "secret"}})
+	outbox.Publish(purchase("ticket-1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	events.NewDispatcher(outbox).DeliverDue(ctx)
+	pending, dead := outbox.Snapshot()
+	if len(pending) != 1 || pending[0].Attempts != 0 || len(dead) != 0 {
+		t.Errorf("Expected the delivery to stay pending untouched, got %+v and %+v", pending, dead)
+	}
+}
+
+func TestRunDeliversPublishedEvents(t *testing.T) {
+	webhook := newReceiver(t, "secret")
+	outbox := events.NewOutbox([]events.Webhook{{Name: "crm", URL: webhook.URL, Secret: "secret"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		events.NewDispatcher(outbox).Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	outbox.Publish(purchase("ticket-1"))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(webhook.events(t)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("The event was not delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRestoreDeadLettersUnknownWebhooks(t *testing.T) {
+	outbox := events.NewOutbox([]events.Webhook{{Name: "crm", URL: "http://127.0.0.1:1", Secret: "secret"}})
+	outbox.Restore([]*events.Delivery{
+		{Event: purchase("ticket-1"), Webhook: "crm"},
+		{Event: purchase("ticket-2"), Webhook: "retired"},
+	}, nil)
+	pending, dead := outbox.Snapshot()
+	if len(pending) != 1 || pending[0].Webhook != "crm" {
+		t.Errorf("Expected the crm delivery to stay pending, got %+v", pending)
+	}
+	if len(dead) != 1 || dead[0].Webhook != "retired" || dead[0].LastError == "" {
+		t.Errorf("Expected the delivery to the removed webhook to be dead-lettered, got %+v", dead)
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	write := func(contents string) string {
+		path := filepath.Join(dir, "webhooks.json")
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	webhooks, err := events.LoadWebhooks(write(`[{"name":"crm","url":"https://crm.example.com/hooks","secret":"s","types":["TicketPurchased"]}]`))
+	if err != nil {
+		t.Fatalf("LoadWebhooks failed: %v", err)
+	}
+	if len(webhooks) != 1 || webhooks[0].Name != "crm" || len(webhooks[0].Types) != 1 {
+		t.Errorf("Unexpected webhooks %+v", webhooks)
+	}
+	for _, contents := range []string{
+		`[{"name":"crm","url":"https://crm.example.com/hooks"}]`,
+		`[{"name":"crm","url":"https://a.example.com","secret":"s"},{"name":"crm","url":"https://b.example.com","secret":"s"}]`,
+		`[{"name":"crm","url":"https://crm.example.com/hooks","secret":"s","types":["TicketRefunded"]}]`,
+		`{"name":"crm"}`,
+	} {
+		if _, err := events.LoadWebhooks(write(contents)); err == nil {
+			t.Errorf("Expected %s to be rejected", contents)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+// Package gateway serves the booking API to clients that cannot speak gRPC,
+// such as browsers, in two ways:
+//
+//   - as HTTP/JSON: grpc-gateway translates each request into a call of the RPC
+//     whose google.api.http annotation matches it, under /v1/ for the v1 API
+//     and /v2/ for the v2 one, mapping messages to JSON as protojson does;
+//   - as gRPC-Web: POST requests with an application/grpc-web content type are
+//     unwrapped into gRPC calls, for generated gRPC-Web stubs. Browsers cannot
+//     stream requests, so ImportBookings is out of their reach.
+//
+// Both go to a gRPC server private to the gateway, so that calls pass through
+// the same interceptors, such as authentication, logging and metrics, as calls
+// made over gRPC. Browsers on other origins may call the gateway once their
+// origin is allowed with WithAllowedOrigins.
+//
+// Client certificates authenticate gRPC-Web calls only: those are served from
+// the HTTP request, TLS state included, while grpc-gateway calls the private
+// server over a plaintext in-process connection that carries no certificate.
+// HTTP/JSON clients authenticate with a bearer token or an API key.
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"errors"
+	"net"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/ratelimit"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/rs/cors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// OpenAPI and OpenAPIV2 are the OpenAPI 2.0 documents of the v1 and v2
+// HTTP/JSON APIs, generated from their protos by protoc-gen-openapiv2.
+var (
+	//go:embed openapi/booking-service/v1/booking.swagger.json
+	OpenAPI []byte
+	//go:embed openapi/bookmyseat/booking/v2/booking.swagger.json
+	OpenAPIV2 []byte
+)
+
+// OpenAPIPath and OpenAPIV2Path are where the OpenAPI documents are served.
+const (
+	OpenAPIPath   = "/openapi.json"
+	OpenAPIV2Path = "/v2/openapi.json"
+)
+
+// Server serves the HTTP/JSON API.
+type Server struct {
+	http     *http.Server
+	backend  *grpc.Server
+	listener *bufconn.Listener
+	conn     *grpc.ClientConn
+}
+
+// Option configures a Server.
+type Option func(*options)
+
+type options struct {
+	allowedOrigins []string
+}
+
+// WithAllowedOrigins lets browsers on the given origins, such as
+// "https://app.example.com", call the API, including with gRPC-Web. "*" allows
+// every origin.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(o *options) {
+		o.allowedOrigins = append(o.allowedOrigins, origins...)
+	}
+}
+
+// corsRequestHeaders are the headers browsers may send to the HTTP/JSON API
+// across origins, and corsResponseHeaders those they may read. gRPC-Web
+// requests may carry any header, as metadata.
+var (
+	corsRequestHeaders  = []string{"Authorization", "Content-Type", auth.APIKeyHeader, logging.RequestIDHeader}
+	corsResponseHeaders = []string{logging.RequestIDHeader, ratelimit.RetryAfterHeader}
+)
+
+// New returns a gateway calling backend, which must have both versions of the
+// booking service registered and must not be serving yet. Requests are served
+// over HTTPS when tlsConfig is set.
+func New(backend *grpc.Server, tlsConfig *tls.Config, opts ...Option) (*Server, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	listener := bufconn.Listen(1 << 20)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	translator := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
+	for _, register := range []func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error{
+		pb.RegisterBookingServiceHandler,
+		bookingv2.RegisterBookingServiceHandler,
+	} {
+		if err := register(context.Background(), translator, conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", translator)
+	mux.Handle("/v2/", translator)
+	mux.Handle(OpenAPIPath, openAPIHandler(OpenAPI))
+	mux.Handle(OpenAPIV2Path, openAPIHandler(OpenAPIV2))
+	var routes http.Handler = mux
+	if len(o.allowedOrigins) > 0 {
+		routes = cors.New(cors.Options{
+			AllowedOrigins: o.allowedOrigins,
+			AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete},
+			AllowedHeaders: corsRequestHeaders,
+			ExposedHeaders: corsResponseHeaders,
+			MaxAge:         int((10 * time.Minute).Seconds()),
+		}).Handler(routes)
+	}
+	grpcWeb := grpcweb.WrapServer(backend, grpcweb.WithOriginFunc(originAllowed(o.allowedOrigins)))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if grpcWeb.IsGrpcWebRequest(r) || grpcWeb.IsAcceptableGrpcCorsRequest(r) {
+			grpcWeb.ServeHTTP(w, r)
+			return
+		}
+		routes.ServeHTTP(w, r)
+	})
+	return &Server{
+		http: &http.Server{
+			Handler:           traceContext(handler),
+			TLSConfig:         withHTTP1(tlsConfig),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		backend:  backend,
+		listener: listener,
+		conn:     conn,
+	}, nil
+}
+
+// Serve serves the API on listener until the server is stopped.
+func (s *Server) Serve(listener net.Listener) error {
+	go s.backend.Serve(s.listener)
+	var err error
+	if s.http.TLSConfig != nil {
+		err = s.http.ServeTLS(listener, "", "")
+	} else {
+		err = s.http.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// GracefulStop stops accepting requests and waits for those in flight to
+// finish.
+func (s *Server) GracefulStop() {
+	_ = s.http.Shutdown(context.Background())
+	s.conn.Close()
+	s.backend.GracefulStop()
+}
+
+// Stop closes every connection, cancelling the requests in flight.
+func (s *Server) Stop() {
+	_ = s.http.Close()
+	s.conn.Close()
+	s.backend.Stop()
+}
+
+// incomingHeader forwards the API key and request ID headers as gRPC metadata
+// on top of those grpc-gateway forwards by default, such as Authorization.
+func incomingHeader(key string) (string, bool) {
+	switch key = textproto.CanonicalMIMEHeaderKey(key); key {
+	case textproto.CanonicalMIMEHeaderKey(auth.APIKeyHeader), textproto.CanonicalMIMEHeaderKey(logging.RequestIDHeader):
+		return key, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeader returns the request ID and the retry delay of rate limited
+// calls as plain headers, and other response metadata with the Grpc-Metadata-
+// prefix.
+func outgoingHeader(key string) (string, bool) {
+	switch key {
+	case logging.RequestIDHeader, ratelimit.RetryAfterHeader:
+		return textproto.CanonicalMIMEHeaderKey(key), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// traceContext continues the trace of the caller, when the request carries
+// one, through the gRPC call.
+func traceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// openAPIHandler serves document.
+func openAPIHandler(document []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	})
+}
+
+// originAllowed reports whether an origin is one of allowed.
+func originAllowed(allowed []string) func(origin string) bool {
+	return func(origin string) bool {
+		for _, candidate := range allowed {
+			if candidate == "*" || candidate == origin {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// withHTTP1 lets HTTP/1.1 clients negotiate with a server configuration that
+// only offers HTTP/2, as the gRPC one does.
+func withHTTP1(config *tls.Config) *tls.Config {
+	if config == nil {
+		return nil
+	}
+	config = config.Clone()
+	getConfig := config.GetConfigForClient
+	if getConfig == nil {
+		config.NextProtos = []string{"h2", "http/1.1"}
+		return config
+	}
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		perClient, err := getConfig(hello)
+		if err != nil || perClient == nil {
+			return perClient, err
+		}
+		perClient = perClient.Clone()
+		perClient.NextProtos = []string{"h2", "http/1.1"}
+		return perClient, nil
+	}
+	return config
+}
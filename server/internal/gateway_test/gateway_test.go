@@ -0,0 +1,285 @@
+package gateway_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/gateway"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/ratelimit"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc"
+)
+
+// startGateway serves server through a gateway with gatewayOpts whose backend
+// has serverOpts, and returns the base URL of the gateway.
+func startGateway(t *testing.T, server *api.BookingServiceServer, gatewayOpts []gateway.Option, serverOpts ...grpc.ServerOption) string {
+	t.Helper()
+	backend := grpc.NewServer(serverOpts...)
+	pb.RegisterBookingServiceServer(backend, server)
+	bookingv2.RegisterBookingServiceServer(backend, api.NewBookingServiceV2Server(server))
+	gw, err := gateway.New(backend, nil, gatewayOpts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gw.Serve(listen)
+	t.Cleanup(gw.Stop)
+	return "http://" + listen.Addr().String()
+}
+
+// call sends body as JSON, when set, and decodes the JSON response into out,
+// when set. It returns the response, whose body is already read.
+func call(t *testing.T, method, url, body string, header http.Header, out interface{}) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(raw, out); err != nil {
+			t.Fatalf("%s %s: decoding %s failed: %v", method, url, raw, err)
+		}
+	}
+	return resp
+}
+
+type ticket struct {
+	ID          string `json:"id"`
+	SeatSection string `json:"seatSection"`
+	SeatNumber  int    `json:"seatNumber"`
+	User        struct {
+		Email string `json:"email"`
+	} `json:"user"`
+}
+
+func TestBookingOverHTTP(t *testing.T) {
+	url := startGateway(t, api.NewBookingServiceServer(), nil)
+
+	var purchased struct{ Ticket ticket }
+	resp := call(t, http.MethodPost, url+"/v1/tickets",
+		`{"user": {"firstName": "John", "lastName": "Doe", "email": "john@example.com"}, "seatSection": "A", "seatNumber": 1, "ticketPrice": 20}`,
+		nil, &purchased)
+	if resp.StatusCode != http.StatusOK || purchased.Ticket.ID == "" {
+		t.Fatalf("Expected the ticket to be purchased, got %s and %+v", resp.Status, purchased)
+	}
+
+	var receipt struct{ Ticket ticket }
+	if resp := call(t, http.MethodGet, url+"/v1/passengers/john@example.com/receipt", "", nil, &receipt); resp.StatusCode != http.StatusOK || receipt.Ticket.ID != purchased.Ticket.ID {
+		t.Errorf("Expected the receipt of ticket %s, got %s and %+v", purchased.Ticket.ID, resp.Status, receipt)
+	}
+
+	var list struct {
+		Tickets   []ticket
+		TotalSize int
+	}
+	if resp := call(t, http.MethodGet, url+"/v1/tickets?filter.seatSection=A", "", nil, &list); resp.StatusCode != http.StatusOK || list.TotalSize != 1 || len(list.Tickets) != 1 {
+		t.Errorf("Expected one ticket in section A, got %s and %+v", resp.Status, list)
+	}
+
+	if resp := call(t, http.MethodPatch, url+"/v1/passengers/john@example.com/seat", `{"newSeatSection": "B", "newSeatNumber": 4}`, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the seat to be changed, got %s", resp.Status)
+	}
+	if call(t, http.MethodGet, url+"/v1/passengers/john@example.com/receipt", "", nil, &receipt); receipt.Ticket.SeatSection != "B" || receipt.Ticket.SeatNumber != 4 {
+		t.Errorf("Expected seat B4, got %+v", receipt.Ticket)
+	}
+
+	if resp := call(t, http.MethodDelete, url+"/v1/passengers/john@example.com", "", nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the passenger to be removed, got %s", resp.Status)
+	}
+	if resp := call(t, http.MethodGet, url+"/v1/passengers/john@example.com/receipt:render", "", nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for the removed passenger, got %s", resp.Status)
+	}
+}
+
+type ticketV2 struct {
+	Name      string
+	Seat      string
+	Passenger struct {
+		Email string
+	}
+}
+
+func TestBookingOverHTTPV2(t *testing.T) {
+	url := startGateway(t, api.NewBookingServiceServer(), nil)
+
+	var created ticketV2
+	resp := call(t, http.MethodPost, url+"/v2/tickets",
+		`{"seat": "departures/london-france/seats/A1", "passenger": {"givenName": "John", "email": "john@example.com"}, "price": 20}`,
+		nil, &created)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(created.Name, "tickets/") {
+		t.Fatalf("Expected the ticket to be created, got %s and %+v", resp.Status, created)
+	}
+	if resp := call(t, http.MethodPost, url+"/v2/tickets", `{"seat": "departures/london-france/seats/A1", "passenger": {"email": "kim@example.com"}}`, nil, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a taken seat, got %s", resp.Status)
+	}
+
+	var got ticketV2
+	if resp := call(t, http.MethodGet, url+"/v2/"+created.Name, "", nil, &got); resp.StatusCode != http.StatusOK || got.Passenger.Email != "john@example.com" {
+		t.Errorf("Expected ticket %s, got %s and %+v", created.Name, resp.Status, got)
+	}
+	// The ticket is the same booking through v1.
+	var receipt struct{ Ticket ticket }
+	if call(t, http.MethodGet, url+"/v1/passengers/john@example.com/receipt", "", nil, &receipt); "tickets/"+receipt.Ticket.ID != created.Name {
+		t.Errorf("Expected the v1 receipt of %s, got %+v", created.Name, receipt)
+	}
+
+	if resp := call(t, http.MethodPatch, url+"/v2/"+created.Name+"?updateMask=seat", `{"seat": "departures/london-france/seats/B4"}`, nil, &got); resp.StatusCode != http.StatusOK || got.Seat != "departures/london-france/seats/B4" {
+		t.Errorf("Expected the ticket to move to B4, got %s and %+v", resp.Status, got)
+	}
+	var seats struct{ Seats []struct{ Name string } }
+	if resp := call(t, http.MethodGet, url+"/v2/departures/london-france/seats?pageSize=1&availableOnly=true", "", nil, &seats); resp.StatusCode != http.StatusOK || len(seats.Seats) != 1 {
+		t.Errorf("Expected a page of one seat, got %s and %+v", resp.Status, seats)
+	}
+
+	if resp := call(t, http.MethodDelete, url+"/v2/"+created.Name, "", nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the ticket to be deleted, got %s", resp.Status)
+	}
+	if resp := call(t, http.MethodGet, url+"/v2/"+created.Name, "", nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for the deleted ticket, got %s", resp.Status)
+	}
+}
+
+func TestErrorsMapToHTTPStatuses(t *testing.T) {
+	url := startGateway(t, api.NewBookingServiceServer(), nil)
+	for _, test := range []struct {
+		method, path, body string
+		expected           int
+	}{
+		{http.MethodPost, "/v1/tickets", `{"user": {"firstName": "John", "email": "not an email"}, "seatSection": "A", "seatNumber": 1}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/tickets", `{"user":`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/tickets/unknown:checkIn", "{}", http.StatusNotFound},
+		{http.MethodGet, "/v1/unknown", "", http.StatusNotFound},
+		{http.MethodDelete, "/v1/passengers/nobody@example.com", "", http.StatusNotFound},
+		{http.MethodPatch, "/v1/passengers/nobody@example.com/seat", `{"newSeatSection": "A", "newSeatNumber": 1}`, http.StatusNotFound},
+		{http.MethodGet, "/v2/departures/paris-rome", "", http.StatusNotFound},
+	} {
+		resp := call(t, test.method, url+test.path, test.body, nil, nil)
+		if resp.StatusCode != test.expected {
+			t.Errorf("%s %s: expected %d, got %s", test.method, test.path, test.expected, resp.Status)
+		}
+		if resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: expected a JSON error, got %s", test.method, test.path, resp.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestRateLimitedCallsAreTooManyRequests(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.WithMethodLimit("PurchaseTicket", ratelimit.Limit{Rate: 1.0 / 60, Burst: 1}))
+	url := startGateway(t, api.NewBookingServiceServer(), nil, grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor()))
+
+	buy := func(email string, seat int) *http.Response {
+		return call(t, http.MethodPost, url+"/v1/tickets",
+			`{"user": {"email": "`+email+`"}, "seatSection": "A", "seatNumber": `+strconv.Itoa(seat)+`}`, nil, nil)
+	}
+	if resp := buy("john@example.com", 1); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the first purchase through, got %s", resp.Status)
+	}
+	resp := buy("jane@example.com", 2)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 for the second purchase, got %s", resp.Status)
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Expected Retry-After: 60, got %q", retryAfter)
+	}
+}
+
+func TestHeadersAreForwarded(t *testing.T) {
+	authenticator := auth.NewAuthenticator(auth.WithPolicy(api.Policy), auth.WithAPIKeys([]auth.APIKey{
+		{Name: "desk", KeySHA256: auth.HashAPIKey("secret"), Roles: []string{auth.RoleAgent}},
+	}))
+	url := startGateway(t, api.NewBookingServiceServer(), nil,
+		grpc.ChainUnaryInterceptor(logging.UnaryInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil))), authenticator.UnaryInterceptor()))
+
+	if resp := call(t, http.MethodGet, url+"/v1/tickets", "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %s", resp.Status)
+	}
+	resp := call(t, http.MethodGet, url+"/v1/tickets", "", http.Header{
+		"X-Api-Key":    {"secret"},
+		"X-Request-Id": {"req-42"},
+	}, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the API key to be accepted, got %s", resp.Status)
+	}
+	if id := resp.Header.Get("X-Request-Id"); id != "req-42" {
+		t.Errorf("Expected the request ID to be echoed, got %q", id)
+	}
+	if resp := call(t, http.MethodGet, url+"/v1/tickets", "", http.Header{"Authorization": {"Bearer not-a-token"}}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected the bearer token to be checked, got %s", resp.Status)
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	url := startGateway(t, api.NewBookingServiceServer(), nil)
+	for _, test := range []struct {
+		path     string
+		service  grpc.ServiceDesc
+		expected map[string]string
+	}{
+		{gateway.OpenAPIPath, pb.BookingService_ServiceDesc, map[string]string{
+			"/v1/tickets":                    "post",
+			"/v1/passengers/{email}/receipt": "get",
+			"/v1/passengers/{email}/seat":    "patch",
+			"/v1/passengers/{email}":         "delete",
+		}},
+		{gateway.OpenAPIV2Path, bookingv2.BookingService_ServiceDesc, map[string]string{
+			"/v2/tickets":        "post",
+			"/v2/{name}":         "get",
+			"/v2/{ticket.name}":  "patch",
+			"/v2/{parent}/seats": "get",
+			"/v2/departures":     "get",
+		}},
+	} {
+		var document struct {
+			Swagger string
+			Paths   map[string]map[string]struct {
+				OperationID string `json:"operationId"`
+			}
+		}
+		resp := call(t, http.MethodGet, url+test.path, "", nil, &document)
+		if resp.StatusCode != http.StatusOK || document.Swagger != "2.0" {
+			t.Fatalf("%s: expected an OpenAPI 2.0 document, got %s and %+v", test.path, resp.Status, document)
+		}
+		for path, method := range test.expected {
+			if _, ok := document.Paths[path][method]; !ok {
+				t.Errorf("%s: expected the document to describe %s %s", test.path, strings.ToUpper(method), path)
+			}
+		}
+		// Every RPC is reachable over HTTP.
+		operations := 0
+		for _, methods := range document.Paths {
+			operations += len(methods)
+		}
+		if expected := len(test.service.Methods) + len(test.service.Streams); operations != expected {
+			t.Errorf("%s: expected %d operations, got %d", test.path, expected, operations)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package gateway_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/gateway"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebCall calls the RPC named method the way a gRPC-Web client does,
+// decoding the response message into out. It returns the gRPC status code and
+// the HTTP response, whose body is already read.
+func grpcWebCall(t *testing.T, url, method string, in, out proto.Message, header http.Header) (codes.Code, *http.Response) {
+	t.Helper()
+	message, err := proto.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	body.WriteByte(0)
+	binary.Write(&body, binary.BigEndian, uint32(len(message)))
+	body.Write(message)
+	req, err := http.NewRequest(http.MethodPost, url+"/"+pb.BookingService_ServiceDesc.ServiceName+"/"+method, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("X-Grpc-Web", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Calling %s failed: %v", method, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A trailers-only response carries the status in its headers, others in a
+	// trailer frame after the message.
+	grpcStatus := resp.Header.Get("Grpc-Status")
+	for len(raw) >= 5 {
+		flags, length := raw[0], binary.BigEndian.Uint32(raw[1:5])
+		if uint32(len(raw)-5) < length {
+			t.Fatalf("Truncated gRPC-Web frame in %q", raw)
+		}
+		frame := raw[5 : 5+length]
+		raw = raw[5+length:]
+		if flags&0x80 == 0 {
+			if err := proto.Unmarshal(frame, out); err != nil {
+				t.Fatalf("Decoding the %s response failed: %v", method, err)
+			}
+			continue
+		}
+		for _, line := range strings.Split(string(frame), "\r\n") {
+			if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "grpc-status") {
+				grpcStatus = strings.TrimSpace(value)
+			}
+		}
+	}
+	code, err := strconv.Atoi(grpcStatus)
+	if err != nil {
+		t.Fatalf("Expected a grpc-status from %s, got %q", method, grpcStatus)
+	}
+	return codes.Code(code), resp
+}
+
+func TestGRPCWebCalls(t *testing.T) {
+	url := startGateway(t, api.NewBookingServiceServer(), nil,
+		grpc.ChainUnaryInterceptor(logging.UnaryInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil)))))
+
+	var purchased pb.PurchaseTicketResponse
+	code, resp := grpcWebCall(t, url, "PurchaseTicket", &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "john@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	}, &purchased, http.Header{"X-Request-Id": {"web-1"}})
+	if code != codes.OK || purchased.Ticket.GetId() == "" {
+		t.Fatalf("Expected the ticket to be purchased, got %v and %v", code, &purchased)
+	}
+	if id := resp.Header.Get("X-Request-Id"); id != "web-1" {
+		t.Errorf("Expected the request ID as response metadata, got %q", id)
+	}
+
+	var receipt pb.GetReceiptResponse
+	if code, _ := grpcWebCall(t, url, "GetReceipt", &pb.GetReceiptRequest{Email: "john@example.com"}, &receipt, nil); code != codes.OK || receipt.Ticket.GetId() != purchased.Ticket.Id {
+		t.Errorf("Expected the receipt of ticket %s, got %v and %v", purchased.Ticket.Id, code, &receipt)
+	}
+	if code, _ := grpcWebCall(t, url, "RenderReceipt", &pb.RenderReceiptRequest{Email: "nobody@example.com"}, &pb.RenderReceiptResponse{}, nil); code != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown passenger, got %v", code)
+	}
+}
+
+// preflight sends the CORS preflight request of a browser on origin about to
+// call path with headers.
+func preflight(t *testing.T, url, path, origin, headers string) http.Header {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodOptions, url+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", headers)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Preflight of %s failed: %v", path, err)
+	}
+	resp.Body.Close()
+	return resp.Header
+}
+
+func TestCrossOriginRequests(t *testing.T) {
+	const origin = "https://app.example.com"
+	purchase := "/" + pb.BookingService_ServiceDesc.ServiceName + "/PurchaseTicket"
+	url := startGateway(t, api.NewBookingServiceServer(), []gateway.Option{gateway.WithAllowedOrigins(origin)},
+		grpc.ChainUnaryInterceptor(logging.UnaryInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil)))))
+
+	if header := preflight(t, url, purchase, origin, "content-type,x-grpc-web,x-api-key"); header.Get("Access-Control-Allow-Origin") != origin {
+		t.Errorf("Expected gRPC-Web calls from %s to be allowed, got %v", origin, header)
+	}
+	if header := preflight(t, url, "/v1/tickets", origin, "content-type,x-api-key"); header.Get("Access-Control-Allow-Origin") != origin {
+		t.Errorf("Expected JSON calls from %s to be allowed, got %v", origin, header)
+	}
+	for _, path := range []string{purchase, "/v1/tickets"} {
+		if header := preflight(t, url, path, "https://evil.example.com", "content-type,x-grpc-web"); header.Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s: expected other origins to be refused, got %v", path, header)
+		}
+	}
+
+	resp := call(t, http.MethodGet, url+"/v1/tickets", "", http.Header{"Origin": {origin}}, nil)
+	if resp.Header.Get("Access-Control-Allow-Origin") != origin || !strings.Contains(resp.Header.Get("Access-Control-Expose-Headers"), "X-Request-Id") {
+		t.Errorf("Expected the response to be readable from %s with its request ID, got %v", origin, resp.Header)
+	}
+
+	closed := startGateway(t, api.NewBookingServiceServer(), nil)
+	if header := preflight(t, closed, "/v1/tickets", origin, "content-type"); header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected no origin to be allowed by default, got %v", header)
+	}
+}
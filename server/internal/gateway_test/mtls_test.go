@@ -0,0 +1,106 @@
+package gateway_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/gateway"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// issue returns a certificate for subject signed by ca, usable by servers for
+// 127.0.0.1 when server is set and by clients otherwise.
+func issue(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64, subject pkix.Name, server bool) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating key failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Creating certificate failed: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientCertificatesAuthenticateGRPCWebOnly(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating CA key failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Creating CA certificate failed: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	authenticator := auth.NewAuthenticator(auth.WithClientCertificates(), auth.WithPolicy(api.Policy))
+	backend := grpc.NewServer(grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()))
+	server := api.NewBookingServiceServer()
+	pb.RegisterBookingServiceServer(backend, server)
+	bookingv2.RegisterBookingServiceServer(backend, api.NewBookingServiceV2Server(server))
+	gw, err := gateway.New(backend, &tls.Config{
+		Certificates: []tls.Certificate{issue(t, ca, caKey, 2, pkix.Name{CommonName: "book-my-seat"}, true)},
+		ClientCAs:    roots,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gw.Serve(listen)
+	t.Cleanup(gw.Stop)
+	url := "https://" + listen.Addr().String()
+
+	admin := issue(t, ca, caKey, 3, pkix.Name{CommonName: "ops-tool", OrganizationalUnit: []string{auth.RoleAdmin}}, false)
+	defaultClient := http.DefaultClient
+	http.DefaultClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{admin}}}}
+	t.Cleanup(func() { http.DefaultClient = defaultClient })
+
+	if code, _ := grpcWebCall(t, url, "GetUsersAndSeatAllocated", &pb.GetUsersAndSeatAllocatedRequest{SeatSection: pb.SeatSection_A}, &pb.GetUsersAndSeatAllocatedResponse{}, nil); code != codes.OK {
+		t.Errorf("Expected the admin certificate to authenticate a gRPC-Web call, got %v", code)
+	}
+	if resp := call(t, http.MethodGet, url+"/v1/tickets", "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected an HTTP/JSON call to need other credentials, got %s", resp.Status)
+	}
+}
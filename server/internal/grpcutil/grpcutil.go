@@ -0,0 +1,24 @@
+// Package grpcutil holds small helpers shared by the gRPC interceptors.
+package grpcutil
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// WithContext returns stream with its context replaced by ctx, for stream
+// interceptors that pass values or a cancellation on to the handler.
+func WithContext(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &contextStream{ServerStream: stream, ctx: ctx}
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
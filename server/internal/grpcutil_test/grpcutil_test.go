@@ -0,0 +1,30 @@
+package grpcutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/grpcutil"
+	"google.golang.org/grpc"
+)
+
+type key struct{}
+
+// stream is a server stream known only by its context.
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *stream) Context() context.Context { return s.ctx }
+
+func TestWithContext(t *testing.T) {
+	original := &stream{ctx: context.Background()}
+	wrapped := grpcutil.WithContext(original, context.WithValue(original.Context(), key{}, "value"))
+	if got := wrapped.Context().Value(key{}); got != "value" {
+		t.Errorf("Expected the replaced context, got %v", got)
+	}
+	if original.Context().Value(key{}) != nil {
+		t.Errorf("Expected the original stream to keep its context")
+	}
+}
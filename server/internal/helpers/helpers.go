@@ -1 +1,118 @@
 package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// EncodePageToken builds an opaque page token pointing at offset within the
+// result set of the query identified by fingerprint.
+func EncodePageToken(offset int, fingerprint string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + ":" + fingerprint))
+}
+
+// DecodePageToken returns the offset stored in token, failing if the token is
+// malformed or was issued for a different query than fingerprint.
+func DecodePageToken(token string, fingerprint string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("malformed page token")
+	}
+	offsetStr, tokenFingerprint, found := strings.Cut(string(raw), ":")
+	if !found || tokenFingerprint != fingerprint {
+		return 0, fmt.Errorf("page token does not match the request")
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed page token")
+	}
+	return offset, nil
+}
+
+// Fingerprint identifies query, a request holding only the fields that select
+// and order results, so that a page token cannot be replayed against another.
+func Fingerprint(query proto.Message) string {
+	raw, _ := proto.MarshalOptions{Deterministic: true}.Marshal(query)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Page returns the bounds of the page of at most pageSize out of count results
+// that token points at, and the token of the page after it, "" for the last.
+// An empty token points at the first page. query identifies the results, see
+// Fingerprint.
+func Page(token string, query proto.Message, count, pageSize int) (start, end int, next string, err error) {
+	fingerprint := Fingerprint(query)
+	if token != "" {
+		if start, err = DecodePageToken(token, fingerprint); err != nil {
+			return 0, 0, "", err
+		}
+	}
+	if start > count {
+		start = count
+	}
+	end = start + pageSize
+	if end > count {
+		end = count
+	}
+	if end < count {
+		next = EncodePageToken(end, fingerprint)
+	}
+	return start, end, next, nil
+}
+
+// EmailNormalizer turns an email address into the key that identifies a user, so
+// that differently typed forms of the same address map to one booking.
+type EmailNormalizer struct {
+	// ProviderRules enables provider specific canonicalisation, such as Gmail
+	// ignoring dots and "+tag" suffixes in the local part.
+	ProviderRules bool
+}
+
+// providerRule describes how a mail provider treats the local part of its addresses.
+type providerRule struct {
+	canonicalDomain string
+	stripDots       bool
+	stripPlusTag    bool
+}
+
+var providerRules = map[string]providerRule{
+	"gmail.com":      {canonicalDomain: "gmail.com", stripDots: true, stripPlusTag: true},
+	"googlemail.com": {canonicalDomain: "gmail.com", stripDots: true, stripPlusTag: true},
+	"outlook.com":    {canonicalDomain: "outlook.com", stripPlusTag: true},
+	"hotmail.com":    {canonicalDomain: "hotmail.com", stripPlusTag: true},
+	"icloud.com":     {canonicalDomain: "icloud.com", stripPlusTag: true},
+	"fastmail.com":   {canonicalDomain: "fastmail.com", stripPlusTag: true},
+}
+
+// Normalize trims and lower-cases email and, if enabled, applies provider rules.
+// It fails if email is not of the form local@domain.
+func (n EmailNormalizer) Normalize(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, found := strings.Cut(email, "@")
+	if !found || local == "" || domain == "" || strings.Contains(domain, "@") || strings.ContainsAny(email, " \t\r\n") {
+		return "", fmt.Errorf("invalid email address %q", email)
+	}
+	domain = strings.TrimSuffix(domain, ".")
+	if n.ProviderRules {
+		if rule, ok := providerRules[domain]; ok {
+			if rule.stripPlusTag {
+				local, _, _ = strings.Cut(local, "+")
+			}
+			if rule.stripDots {
+				local = strings.ReplaceAll(local, ".", "")
+			}
+			if local == "" {
+				return "", fmt.Errorf("invalid email address %q", email)
+			}
+			domain = rule.canonicalDomain
+		}
+	}
+	return local + "@" + domain, nil
+}
@@ -0,0 +1,36 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/helpers"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+func TestPage(t *testing.T) {
+	query := &pb.SearchPassengersRequest{Query: "smith"}
+	var pages [][2]int
+	token := ""
+	for {
+		start, end, next, err := helpers.Page(token, query, 5, 2)
+		if err != nil {
+			t.Fatalf("Page failed: %v", err)
+		}
+		pages = append(pages, [2]int{start, end})
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if len(pages) != 3 || pages[0] != [2]int{0, 2} || pages[1] != [2]int{2, 4} || pages[2] != [2]int{4, 5} {
+		t.Errorf("Expected pages [0,2) [2,4) [4,5), got %v", pages)
+	}
+
+	_, _, next, _ := helpers.Page("", query, 5, 2)
+	if _, _, _, err := helpers.Page(next, &pb.SearchPassengersRequest{Query: "jones"}, 5, 2); err == nil {
+		t.Errorf("Expected a token to be rejected for another query")
+	}
+	if start, end, next, err := helpers.Page(next, query, 1, 2); err != nil || start != 1 || end != 1 || next != "" {
+		t.Errorf("Expected an empty last page once the results shrank, got [%d,%d) %q %v", start, end, next, err)
+	}
+}
@@ -0,0 +1,138 @@
+// Package logging gives every RPC a request ID and a structured logger carrying
+// it. The ID is taken from the x-request-id metadata of the call, or generated
+// when the caller sent none, and is returned to the caller in the response
+// headers so that client and server logs can be matched up.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/grpcutil"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the metadata key carrying the request ID.
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds the length of a request ID accepted from a caller.
+const maxRequestIDLength = 128
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request, or the default logger outside
+// of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID of the request handled under ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID returns the request ID sent by the caller, or a new one when none or
+// a malformed one was sent.
+func requestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(RequestIDHeader); len(values) > 0 && validRequestID(values[0]) {
+		return values[0]
+	}
+	return uuid.NewString()
+}
+
+// validRequestID accepts IDs short enough and plain enough to be logged as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// begin attaches the request ID and a logger carrying it, along with the trace
+// ID when the call is traced, to ctx.
+func begin(ctx context.Context, logger *slog.Logger, method string) (context.Context, string) {
+	id := requestID(ctx)
+	attrs := []any{slog.String("request_id", id), slog.String("method", method)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", span.TraceID().String()))
+	}
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return NewContext(ctx, logger.With(attrs...)), id
+}
+
+// finish logs the outcome of the request. Failures caused by the server are
+// logged as errors, those caused by the caller as warnings.
+func finish(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.Duration("duration", time.Since(start)),
+		slog.String("code", code.String()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	FromContext(ctx).LogAttrs(ctx, level, "finished call", attrs...)
+}
+
+// UnaryInterceptor logs every unary call with logger and makes the request
+// logger available to the handler through FromContext.
+func UnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, id := begin(ctx, logger, info.FullMethod)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+		resp, err := handler(ctx, req)
+		finish(ctx, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor logs every streaming call with logger and makes the request
+// logger available to the handler through FromContext.
+func StreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, id := begin(stream.Context(), logger, info.FullMethod)
+		_ = stream.SetHeader(metadata.Pairs(RequestIDHeader, id))
+		err := handler(srv, grpcutil.WithContext(stream, ctx))
+		finish(ctx, start, err)
+		return err
+	}
+}
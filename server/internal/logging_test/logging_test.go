@@ -0,0 +1,169 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// logBuffer collects the JSON log lines written by the server.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) records(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func newClient(t *testing.T) (pb.BookingServiceClient, *logBuffer) {
+	t.Helper()
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logging.UnaryInterceptor(logger)),
+		grpc.ChainStreamInterceptor(logging.StreamInterceptor(logger)),
+	)
+	pb.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceServer())
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBookingServiceClient(conn), logs
+}
+
+func purchaseRequest(seat uint32) *pb.PurchaseTicketRequest {
+	return &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  seat,
+		TicketPrice: 20,
+	}
+}
+
+func TestRequestIDFromMetadata(t *testing.T) {
+	client, logs := newClient(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), logging.RequestIDHeader, "booking-42")
+	var header metadata.MD
+	if _, err := client.PurchaseTicket(ctx, purchaseRequest(1), grpc.Header(&header)); err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	if got := header.Get(logging.RequestIDHeader); len(got) != 1 || got[0] != "booking-42" {
+		t.Errorf("Expected the request ID to be echoed, got %v", got)
+	}
+
+	messages := map[string]map[string]interface{}{}
+	for _, record := range logs.records(t) {
+		if record["request_id"] != "booking-42" {
+			t.Errorf("Expected every line to carry the request ID, got %v", record)
+		}
+		messages[record["msg"].(string)] = record
+	}
+	purchased, ok := messages["ticket purchased"]
+	if !ok || purchased["ticket_id"] == "" || purchased["seat"] != float64(1) {
+		t.Errorf("Expected the handler to log the purchase, got %v", purchased)
+	}
+	finished, ok := messages["finished call"]
+	if !ok {
+		t.Fatalf("Expected the call to be logged, got %v", messages)
+	}
+	if finished["method"] != "/BookingService.BookingService/PurchaseTicket" || finished["code"] != "OK" || finished["level"] != "INFO" {
+		t.Errorf("Unexpected call log %v", finished)
+	}
+	if _, ok := finished["peer"]; !ok {
+		t.Errorf("Expected the peer to be logged, got %v", finished)
+	}
+	if _, ok := finished["duration"]; !ok {
+		t.Errorf("Expected the duration to be logged, got %v", finished)
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	client, logs := newClient(t)
+	for _, sent := range []string{"", "has spaces; and=symbols"} {
+		ctx := context.Background()
+		if sent != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, logging.RequestIDHeader, sent)
+		}
+		var header metadata.MD
+		client.GetReceipt(ctx, &pb.GetReceiptRequest{Email: "john.doe@example.com"}, grpc.Header(&header))
+		got := header.Get(logging.RequestIDHeader)
+		if len(got) != 1 || got[0] == "" || got[0] == sent {
+			t.Errorf("Expected a generated request ID for %q, got %v", sent, got)
+		}
+	}
+	records := logs.records(t)
+	if len(records) != 2 || records[0]["request_id"] == records[1]["request_id"] {
+		t.Errorf("Expected one line per call with distinct request IDs, got %v", records)
+	}
+}
+
+func TestFailedCallsLogged(t *testing.T) {
+	client, logs := newClient(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), logging.RequestIDHeader, "retry-1")
+	if _, err := client.PurchaseTicket(ctx, purchaseRequest(1)); err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	var header metadata.MD
+	_, err := client.PurchaseTicket(ctx, purchaseRequest(2), grpc.Header(&header))
+	if err == nil {
+		t.Fatalf("Expected a second booking for the same passenger to fail")
+	}
+	if got := header.Get(logging.RequestIDHeader); len(got) != 1 || got[0] != "retry-1" {
+		t.Errorf("Expected the request ID on failed calls too, got %v", got)
+	}
+	records := logs.records(t)
+	last := records[len(records)-1]
+	if last["msg"] != "finished call" || last["level"] == "INFO" || !strings.Contains(last["error"].(string), "already booked") {
+		t.Errorf("Expected the failure to be logged with its cause, got %v", last)
+	}
+}
+
+func TestFromContextOutsideRequests(t *testing.T) {
+	if logging.FromContext(context.Background()) != slog.Default() {
+		t.Errorf("Expected the default logger outside of requests")
+	}
+	if id := logging.RequestID(context.Background()); id != "" {
+		t.Errorf("Expected no request ID outside of requests, got %q", id)
+	}
+}
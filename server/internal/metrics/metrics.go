@@ -0,0 +1,129 @@
+// Package metrics exposes Prometheus metrics about the RPCs served and the
+// state of the bookings.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Namespace prefixes the name of every metric.
+const Namespace = "bookmyseat"
+
+// Metrics is a registry of the server's metrics together with the interceptors
+// recording them.
+type Metrics struct {
+	registry    *prometheus.Registry
+	rpcDuration *prometheus.HistogramVec
+	rpcErrors   *prometheus.CounterVec
+}
+
+// New creates the RPC metrics along with the Go runtime and process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "rpc_duration_seconds",
+			Help:      "Time taken to handle RPCs, by full method and status code.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"method", "code"}),
+		rpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "rpc_errors_total",
+			Help:      "RPCs that failed, by full method and status code.",
+		}, []string{"method", "code"}),
+	}
+	m.registry.MustRegister(
+		m.rpcDuration,
+		m.rpcErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// RegisterBookings adds gauges describing the bookings of server. They are
+// computed from the bookings on every scrape.
+func (m *Metrics) RegisterBookings(server *api.BookingServiceServer) error {
+	return m.registry.Register(bookingCollector{server: server})
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Gatherer returns the registry holding the metrics.
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
+
+func (m *Metrics) observe(method string, start time.Time, err error) {
+	code := status.Code(err)
+	m.rpcDuration.WithLabelValues(method, code.String()).Observe(time.Since(start).Seconds())
+	if code != codes.OK {
+		m.rpcErrors.WithLabelValues(method, code.String()).Inc()
+	}
+}
+
+// UnaryInterceptor records the duration and outcome of unary RPCs. It should
+// run first so that calls rejected by later interceptors are counted too.
+func (m *Metrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor records the duration and outcome of streaming RPCs.
+func (m *Metrics) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		m.observe(info.FullMethod, start, err)
+		return err
+	}
+}
+
+var (
+	seatsSoldDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "seats_sold"),
+		"Booked seats, by section.", []string{"section"}, nil)
+	seatsFreeDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "seats_free"),
+		"Seats still available for booking, by section.", []string{"section"}, nil)
+	revenueDesc = prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "revenue_dollars"),
+		"Sum of the prices paid for the current tickets, by section.", []string{"section"}, nil)
+)
+
+// bookingCollector reports the occupancy of the train at scrape time. There is no
+// holds outstanding gauge: seats are booked outright by PurchaseTicket and never
+// reserved ahead of payment, so no hold is ever outstanding.
+type bookingCollector struct {
+	server *api.BookingServiceServer
+}
+
+func (c bookingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- seatsSoldDesc
+	ch <- seatsFreeDesc
+	ch <- revenueDesc
+}
+
+func (c bookingCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, section := range c.server.Occupancy() {
+		name := section.Section.String()
+		ch <- prometheus.MustNewConstMetric(seatsSoldDesc, prometheus.GaugeValue, float64(section.Sold), name)
+		ch <- prometheus.MustNewConstMetric(seatsFreeDesc, prometheus.GaugeValue, float64(section.Free), name)
+		ch <- prometheus.MustNewConstMetric(revenueDesc, prometheus.GaugeValue, section.Revenue, name)
+	}
+}
@@ -0,0 +1,103 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/metrics"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// scrape returns the metrics served by m in the text exposition format.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatalf("Reading the metrics failed: %v", err)
+	}
+	return string(body)
+}
+
+func expectLines(t *testing.T, exposition string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(exposition, "\n"+line+"\n") {
+			t.Errorf("Expected the metrics to contain %q", line)
+		}
+	}
+}
+
+func TestBookingGauges(t *testing.T) {
+	ctx := context.Background()
+	server := api.NewBookingServiceServer(api.WithLayout(api.Layout{From: "London", To: "France", SeatsPerSection: 10}))
+	for i, section := range []pb.SeatSection{pb.SeatSection_A, pb.SeatSection_A, pb.SeatSection_B} {
+		if _, err := server.PurchaseTicket(ctx, &pb.PurchaseTicketRequest{
+			User:        &pb.User{FirstName: "John", LastName: "Doe", Email: string(rune('a'+i)) + "@example.com"},
+			SeatSection: section,
+			SeatNumber:  uint32(i + 1),
+			TicketPrice: 20,
+		}); err != nil {
+			t.Fatalf("PurchaseTicket failed: %v", err)
+		}
+	}
+	m := metrics.New()
+	if err := m.RegisterBookings(server); err != nil {
+		t.Fatalf("RegisterBookings failed: %v", err)
+	}
+
+	expectLines(t, scrape(t, m),
+		`bookmyseat_seats_sold{section="A"} 2`,
+		`bookmyseat_seats_sold{section="B"} 1`,
+		`bookmyseat_seats_free{section="A"} 8`,
+		`bookmyseat_seats_free{section="B"} 9`,
+		`bookmyseat_revenue_dollars{section="A"} 40`,
+		`bookmyseat_revenue_dollars{section="B"} 20`,
+	)
+
+	// The gauges follow the bookings without re-registering.
+	if _, err := server.RemoveUser(ctx, &pb.RemoveUserRequest{Email: "c@example.com"}); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	expectLines(t, scrape(t, m),
+		`bookmyseat_seats_sold{section="B"} 0`,
+		`bookmyseat_revenue_dollars{section="B"} 0`,
+	)
+}
+
+func TestRPCMetrics(t *testing.T) {
+	m := metrics.New()
+	unary := m.UnaryInterceptor()
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	notFound := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Errorf(codes.NotFound, "no ticket")
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/BookingService.BookingService/GetReceipt"}
+	unary(context.Background(), nil, info, ok)
+	unary(context.Background(), nil, info, notFound)
+	unary(context.Background(), nil, info, notFound)
+
+	stream := m.StreamInterceptor()
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/BookingService.BookingService/ImportBookings", IsClientStream: true}
+	stream(nil, nil, streamInfo, func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Errorf(codes.InvalidArgument, "bad row")
+	})
+
+	expectLines(t, scrape(t, m),
+		`bookmyseat_rpc_duration_seconds_count{code="OK",method="/BookingService.BookingService/GetReceipt"} 1`,
+		`bookmyseat_rpc_duration_seconds_count{code="NotFound",method="/BookingService.BookingService/GetReceipt"} 2`,
+		`bookmyseat_rpc_errors_total{code="NotFound",method="/BookingService.BookingService/GetReceipt"} 2`,
+		`bookmyseat_rpc_errors_total{code="InvalidArgument",method="/BookingService.BookingService/ImportBookings"} 1`,
+	)
+	if exposition := scrape(t, m); strings.Contains(exposition, `bookmyseat_rpc_errors_total{code="OK"`) {
+		t.Errorf("Expected successful calls not to be counted as errors")
+	}
+}
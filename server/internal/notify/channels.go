@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"github.com/google/uuid"
+)
+
+// SMTP sends notifications by email to User.Email.
+type SMTP struct {
+	// Addr is the host:port of the mail server.
+	Addr string
+	// From is the sender address, e.g. "Book My Seat <tickets@example.com>".
+	From string
+	// Username and Password authenticate with AUTH PLAIN when Username is set.
+	// net/smtp only sends them over TLS or to localhost.
+	Username string
+	Password string
+	// Timeout bounds a whole send; zero means 30 seconds.
+	Timeout time.Duration
+}
+
+// Name implements Channel.
+func (c *SMTP) Name() string { return "smtp" }
+
+// Reaches implements Channel.
+func (c *SMTP) Reaches(user *pb.User) bool { return user.GetEmail() != "" }
+
+// Send implements Channel. The connection is upgraded with STARTTLS when the
+// server offers it.
+func (c *SMTP) Send(ctx context.Context, user *pb.User, message Message) error {
+	from, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid sender address: %v", err))
+	}
+	to := &mail.Address{Name: user.GetFirstName() + " " + user.GetLastName(), Address: user.GetEmail()}
+	raw, err := composeEmail(from, to, message)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	host, _, _ := net.SplitHostPort(c.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, host)); err != nil {
+			return Permanent(fmt.Errorf("authenticating: %v", err))
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return smtpError(err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return smtpError(err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+// smtpError marks 5xx replies, which the server will keep giving, permanent.
+func smtpError(err error) error {
+	if reply, ok := err.(*textproto.Error); ok && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
+
+// composeEmail writes message as a quoted-printable UTF-8 text email.
+func composeEmail(from, to *mail.Address, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+	header := []struct{ name, value string }{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + uuid.NewString() + "@book-my-seat>"},
+		{"Content-Language", message.Locale},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, field := range header {
+		fmt.Fprintf(&buf, "%s: %s\r\n", field.name, field.value)
+	}
+	buf.WriteString("\r\n")
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write(bytes.ReplaceAll([]byte(message.Body), []byte("\n"), []byte("\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SMS sends notifications as text messages to User.Phone through an HTTP SMS
+// provider. It POSTs {"to": phone, "body": text} as JSON to URL, with Token as
+// a bearer token.
+type SMS struct {
+	URL   string
+	Token string
+	// Client sends the requests; nil means a client with a 10 second timeout.
+	Client *http.Client
+}
+
+// Name implements Channel.
+func (c *SMS) Name() string { return "sms" }
+
+// Reaches implements Channel.
+func (c *SMS) Reaches(user *pb.User) bool { return user.GetPhone() != "" }
+
+// Send implements Channel.
+func (c *SMS) Send(ctx context.Context, user *pb.User, message Message) error {
+	body, err := json.Marshal(struct {
+		To   string `json:"to"`
+		Body string `json:"body"`
+	}{user.GetPhone(), message.Short})
+	if err != nil {
+		return Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("SMS provider responded %s", resp.Status)
+	default:
+		return Permanent(fmt.Errorf("SMS provider responded %s", resp.Status))
+	}
+}
+
+// Log only logs notifications, for development.
+type Log struct {
+	Logger *slog.Logger
+}
+
+// Name implements Channel.
+func (c *Log) Name() string { return "log" }
+
+// Reaches implements Channel.
+func (c *Log) Reaches(user *pb.User) bool { return true }
+
+// Send implements Channel.
+func (c *Log) Send(ctx context.Context, user *pb.User, message Message) error {
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Info("notification", "email", user.GetEmail(), "phone", user.GetPhone(),
+		"event_type", message.Event, "ticket_id", message.TicketID, "locale", message.Locale, "subject", message.Subject)
+	return nil
+}
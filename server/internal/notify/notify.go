@@ -0,0 +1,205 @@
+// Package notify tells passengers about changes to their bookings. A Notifier
+// writes a message from the templates of the passenger's locale and sends it on
+// every Channel that can reach them, such as email over SMTP or SMS, in the
+// background and retrying failed sends with exponential backoff.
+//
+// Unlike webhook events, notifications are not saved: those still queued or
+// waiting for a retry when the server stops are dropped.
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Message is a notification written for one passenger.
+type Message struct {
+	Event    events.Type
+	TicketID string
+	// Locale is the locale of the templates the message was written from.
+	Locale  string
+	Subject string
+	// Body is the full text, sent by email. Short is a single line, sent by SMS.
+	Body  string
+	Short string
+}
+
+// Channel is a way of reaching passengers.
+type Channel interface {
+	// Name identifies the channel in logs.
+	Name() string
+	// Reaches reports whether user can be reached on the channel, e.g. whether
+	// they gave a phone number for SMS.
+	Reaches(user *pb.User) bool
+	// Send delivers message to user. Errors that retrying cannot fix should be
+	// wrapped with Permanent.
+	Send(ctx context.Context, user *pb.User, message Message) error
+}
+
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// Permanent marks err as a failure that retrying cannot fix, such as an
+// address the provider rejects.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// Notifier sends notifications on its channels.
+type Notifier struct {
+	templates *Templates
+	channels  []Channel
+	retry     events.RetryPolicy
+	logger    *slog.Logger
+	queue     chan send
+	workers   int
+}
+
+// send is one message waiting to be sent on one channel.
+type send struct {
+	channel  Channel
+	user     *pb.User
+	message  Message
+	attempts int
+}
+
+// NotifierOption configures a Notifier.
+type NotifierOption func(*Notifier)
+
+// WithRetryPolicy sets when failed sends are attempted again.
+func WithRetryPolicy(retry events.RetryPolicy) NotifierOption {
+	return func(n *Notifier) {
+		n.retry = retry
+	}
+}
+
+// WithLogger sets where failed sends are logged.
+func WithLogger(logger *slog.Logger) NotifierOption {
+	return func(n *Notifier) {
+		n.logger = logger
+	}
+}
+
+// WithQueueSize sets how many sends may wait for a worker. Notifications
+// arriving while the queue is full are dropped.
+func WithQueueSize(size int) NotifierOption {
+	return func(n *Notifier) {
+		n.queue = make(chan send, size)
+	}
+}
+
+// WithWorkers sets how many sends run concurrently.
+func WithWorkers(workers int) NotifierOption {
+	return func(n *Notifier) {
+		n.workers = workers
+	}
+}
+
+// DefaultRetryPolicy retries a send for about a quarter of an hour.
+var DefaultRetryPolicy = events.RetryPolicy{MaxAttempts: 5, InitialBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+
+// NewNotifier returns a notifier writing messages from templates and sending
+// them on channels.
+func NewNotifier(templates *Templates, channels []Channel, opts ...NotifierOption) *Notifier {
+	n := &Notifier{
+		templates: templates,
+		channels:  channels,
+		retry:     DefaultRetryPolicy,
+		logger:    slog.Default(),
+		queue:     make(chan send, 1000),
+		workers:   4,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Notify queues the message about an event of type eventType for the
+// passenger of the ticket, before and after being the ticket around the
+// change. It never blocks: it is called while the bookings are locked.
+func (n *Notifier) Notify(eventType events.Type, before, after *pb.Ticket) {
+	message, err := n.templates.Render(eventType, before, after)
+	if err != nil {
+		n.logger.Error("failed to write notification", "event_type", eventType, "error", err)
+		return
+	}
+	ticket := after
+	if ticket == nil {
+		ticket = before
+	}
+	for _, channel := range n.channels {
+		if !channel.Reaches(ticket.User) {
+			continue
+		}
+		n.enqueue(send{channel: channel, user: proto.Clone(ticket.User).(*pb.User), message: message})
+	}
+}
+
+func (n *Notifier) enqueue(s send) {
+	select {
+	case n.queue <- s:
+	default:
+		n.logger.Error("notification queue is full, dropping notification",
+			"channel", s.channel.Name(), "event_type", s.message.Event, "ticket_id", s.message.TicketID)
+	}
+}
+
+// Run sends queued notifications until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < n.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case s := <-n.queue:
+					n.attempt(ctx, s)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if dropped := len(n.queue); dropped > 0 {
+		n.logger.Warn("stopping with notifications still queued", "dropped", dropped)
+	}
+}
+
+// attempt sends s once, scheduling a retry if that fails.
+func (n *Notifier) attempt(ctx context.Context, s send) {
+	err := s.channel.Send(ctx, s.user, s.message)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	s.attempts++
+	logger := n.logger.With("channel", s.channel.Name(), "event_type", s.message.Event,
+		"ticket_id", s.message.TicketID, "attempts", s.attempts, "error", err)
+	if IsPermanent(err) || s.attempts >= n.retry.MaxAttempts {
+		logger.Error("giving up on notification")
+		return
+	}
+	wait := n.retry.Backoff(s.attempts)
+	logger.Warn("notification failed, will retry", "retry_in", wait)
+	time.AfterFunc(wait, func() {
+		if ctx.Err() == nil {
+			n.enqueue(s)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+// Package smtptest provides an SMTP server that accepts every message and keeps
+// it in memory, for tests of code sending email.
+package smtptest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email received by a Server.
+type Message struct {
+	// From and To are the envelope sender and recipients.
+	From string
+	To   []string
+	// Header holds the message headers. Subject is the decoded Subject header
+	// and Body the decoded body.
+	Header  mail.Header
+	Subject string
+	Body    string
+	// Username is the name the client authenticated with, if any.
+	Username string
+}
+
+// Server is a local SMTP server. It supports AUTH PLAIN, accepting any
+// credentials, but not STARTTLS.
+type Server struct {
+	// Addr is the host:port the server listens on.
+	Addr string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	received chan struct{}
+}
+
+// NewServer starts a server listening on a free port of the loopback
+// interface. It panics if it cannot listen, like httptest.NewServer.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to listen: %v", err))
+	}
+	s := &Server{Addr: listener.Addr().String(), listener: listener, received: make(chan struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close stops the server and waits for open sessions to end.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// WaitForMessages waits until n messages were received, or timeout passed, and
+// returns the messages received so far.
+func (s *Server) WaitForMessages(n int, timeout time.Duration) []Message {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		messages := append([]Message(nil), s.messages...)
+		received := s.received
+		s.mu.Unlock()
+		if len(messages) >= n {
+			return messages
+		}
+		select {
+		case <-received:
+		case <-deadline:
+			return messages
+		}
+	}
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(time.Minute))
+			s.session(textproto.NewConn(conn))
+		}()
+	}
+}
+
+// session speaks just enough SMTP for net/smtp clients.
+func (s *Server) session(conn *textproto.Conn) {
+	reply := func(format string, args ...interface{}) bool {
+		return conn.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 smtptest ready") {
+		return
+	}
+	var message Message
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if !reply("250-smtptest") || !reply("250-8BITMIME") || !reply("250 AUTH PLAIN") {
+				return
+			}
+		case "HELO", "NOOP":
+			reply("250 OK")
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(argument, " ")
+			if !strings.EqualFold(mechanism, "PLAIN") {
+				reply("504 unsupported mechanism")
+				continue
+			}
+			message.Username = plainUsername(initial)
+			reply("235 authenticated")
+		case "MAIL":
+			message.From = envelopeAddress(argument)
+			message.To = nil
+			reply("250 OK")
+		case "RCPT":
+			message.To = append(message.To, envelopeAddress(argument))
+			reply("250 OK")
+		case "DATA":
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if err := parse(&message, data); err != nil {
+				reply("554 %v", err)
+				continue
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, message)
+			close(s.received)
+			s.received = make(chan struct{})
+			s.mu.Unlock()
+			message = Message{Username: message.Username}
+			reply("250 OK")
+		case "RSET":
+			message = Message{Username: message.Username}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// envelopeAddress extracts the address of a MAIL FROM:<...> or RCPT TO:<...>
+// argument.
+func envelopeAddress(argument string) string {
+	start := strings.Index(argument, "<")
+	end := strings.LastIndex(argument, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return argument[start+1 : end]
+}
+
+func plainUsername(initial string) string {
+	decoded, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		return ""
+	}
+	// The response is authzid NUL authcid NUL password.
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+func parse(message *Message, data []byte) error {
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+	var body io.Reader = parsed.Body
+	if strings.EqualFold(parsed.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(body)
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		return err
+	}
+	message.Header = parsed.Header
+	message.Subject = subject
+	message.Body = string(raw)
+	return nil
+}
@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+//go:embed templates
+var builtinTemplates embed.FS
+
+// defaultLocale is the locale used for passengers whose locale is unset or has
+// no templates. Every template set must include it.
+const defaultLocale = "en"
+
+// eventTypes lists the events every locale needs a template for.
+var eventTypes = []events.Type{events.TicketPurchased, events.SeatChanged, events.TicketCancelled}
+
+// Templates are the messages sent to passengers, by locale and event type.
+// Each locale is a directory named after its BCP 47 tag holding one
+// <event type>.tmpl text/template per event type, which defines the
+// "subject", "body" and "sms" templates.
+type Templates struct {
+	matcher  language.Matcher
+	locales  []string
+	byLocale map[string]map[events.Type]*template.Template
+}
+
+// DefaultTemplates returns the built-in English and French templates.
+func DefaultTemplates() *Templates {
+	sub, err := fs.Sub(builtinTemplates, "templates")
+	if err != nil {
+		panic(err)
+	}
+	templates, err := ParseTemplates(sub)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// LoadTemplates reads templates from the locale directories under dir.
+func LoadTemplates(dir string) (*Templates, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("reading templates: %v", err)
+	}
+	return ParseTemplates(os.DirFS(dir))
+}
+
+// ParseTemplates reads templates from the locale directories at the root of
+// fsys.
+func ParseTemplates(fsys fs.FS) (*Templates, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading templates: %v", err)
+	}
+	t := &Templates{byLocale: make(map[string]map[events.Type]*template.Template)}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tag, err := language.Parse(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("template directory %s is not named after a locale", entry.Name())
+		}
+		locale := tag.String()
+		printer := message.NewPrinter(tag)
+		funcs := template.FuncMap{
+			"price": func(amount float32) string { return printer.Sprintf("%.2f", amount) },
+		}
+		t.byLocale[locale] = make(map[events.Type]*template.Template)
+		for _, eventType := range eventTypes {
+			name := entry.Name() + "/" + string(eventType) + ".tmpl"
+			raw, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, fmt.Errorf("reading templates: %v", err)
+			}
+			parsed, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(raw))
+			if err != nil {
+				return nil, err
+			}
+			for _, part := range []string{"subject", "body", "sms"} {
+				if parsed.Lookup(part) == nil {
+					return nil, fmt.Errorf("%s does not define %q", name, part)
+				}
+			}
+			t.byLocale[locale][eventType] = parsed
+		}
+		t.locales = append(t.locales, locale)
+	}
+	if t.byLocale[defaultLocale] == nil {
+		return nil, fmt.Errorf("templates for the default locale %q are missing", defaultLocale)
+	}
+
+	// The matcher falls back to its first tag.
+	sort.Slice(t.locales, func(i, j int) bool {
+		return t.locales[i] == defaultLocale || (t.locales[j] != defaultLocale && t.locales[i] < t.locales[j])
+	})
+	tags := make([]language.Tag, len(t.locales))
+	for i, locale := range t.locales {
+		tags[i] = language.MustParse(locale)
+	}
+	t.matcher = language.NewMatcher(tags)
+	return t, nil
+}
+
+// Locales lists the locales templates exist for, the default one first.
+func (t *Templates) Locales() []string {
+	return append([]string(nil), t.locales...)
+}
+
+// locale returns the best supported match of the passenger locale.
+func (t *Templates) locale(requested string) string {
+	tag, err := language.Parse(requested)
+	if err != nil {
+		return defaultLocale
+	}
+	_, index, _ := t.matcher.Match(tag)
+	return t.locales[index]
+}
+
+// templateData is what templates are executed with.
+type templateData struct {
+	FirstName string
+	TicketID  string
+	From      string
+	To        string
+	Section   string
+	Seat      uint32
+	Price     float32
+	// PreviousSection and PreviousSeat are the seat before a SeatChanged event.
+	PreviousSection string
+	PreviousSeat    uint32
+}
+
+// Render writes the message about an event of type eventType to the passenger
+// of the ticket, in their locale. before and after are the ticket around the
+// change, either being nil for purchases and cancellations.
+func (t *Templates) Render(eventType events.Type, before, after *pb.Ticket) (Message, error) {
+	ticket := after
+	if ticket == nil {
+		ticket = before
+	}
+	if ticket == nil {
+		return Message{}, fmt.Errorf("no ticket to notify about")
+	}
+	locale := t.locale(ticket.User.GetLocale())
+	parsed, ok := t.byLocale[locale][eventType]
+	if !ok {
+		return Message{}, fmt.Errorf("no template for %s events", eventType)
+	}
+	data := templateData{
+		FirstName: ticket.User.GetFirstName(),
+		TicketID:  ticket.Id,
+		From:      ticket.From,
+		To:        ticket.To,
+		Section:   ticket.SeatSection.String(),
+		Seat:      ticket.SeatNumber,
+		Price:     ticket.PricePaid,
+	}
+	if before != nil && after != nil {
+		data.PreviousSection = before.SeatSection.String()
+		data.PreviousSeat = before.SeatNumber
+	}
+
+	message := Message{Event: eventType, TicketID: ticket.Id, Locale: locale}
+	for _, part := range []struct {
+		name string
+		into *string
+	}{
+		{"subject", &message.Subject},
+		{"body", &message.Body},
+		{"sms", &message.Short},
+	} {
+		var buf bytes.Buffer
+		if err := parsed.ExecuteTemplate(&buf, part.name, data); err != nil {
+			return Message{}, err
+		}
+		*part.into = strings.TrimSpace(buf.String())
+	}
+	message.Body += "\n"
+	return message, nil
+}
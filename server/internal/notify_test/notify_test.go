@@ -0,0 +1,254 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/notify"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/notify/smtptest"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+func ticket(locale string) *pb.Ticket {
+	return &pb.Ticket{
+		Id:          "ticket-1",
+		From:        "London",
+		To:          "France",
+		User:        &pb.User{FirstName: "Amélie", LastName: "Poulain", Email: "amelie@example.com", Phone: "+33612345678", Locale: locale},
+		PricePaid:   20,
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  7,
+	}
+}
+
+// run runs notifier until the test ends.
+func run(t *testing.T, notifier *notify.Notifier) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		notifier.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func quiet() notify.NotifierOption {
+	return notify.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestTemplatesAreLocalized(t *testing.T) {
+	templates := notify.DefaultTemplates()
+	if locales := templates.Locales(); len(locales) < 2 || locales[0] != "en" {
+		t.Errorf("Expected English first among the built-in locales, got %v", locales)
+	}
+	for _, test := range []struct {
+		locale, expectedLocale, subject, price string
+	}{
+		{"", "en", "Your seat from London to France is booked", "$20.00"},
+		{"en-GB", "en", "Your seat from London to France is booked", "$20.00"},
+		{"fr-FR", "fr", "Votre place de London à France est réservée", "20,00 $"},
+		{"ja", "en", "Your seat from London to France is booked", "$20.00"},
+		{"not a locale", "en", "Your seat from London to France is booked", "$20.00"},
+	} {
+		message, err := templates.Render(events.TicketPurchased, nil, ticket(test.locale))
+		if err != nil {
+			t.Fatalf("%q: Render failed: %v", test.locale, err)
+		}
+		if message.Locale != test.expectedLocale || message.Subject != test.subject {
+			t.Errorf("%q: expected %s subject %q, got %s subject %q", test.locale, test.expectedLocale, test.subject, message.Locale, message.Subject)
+		}
+		if !strings.Contains(message.Body, test.price) || !strings.Contains(message.Body, "ticket-1") {
+			t.Errorf("%q: expected the body to show the ticket and %s, got:\n%s", test.locale, test.price, message.Body)
+		}
+		if strings.Contains(message.Short, "\n") || !strings.Contains(message.Short, "A7") {
+			t.Errorf("%q: expected a one line SMS naming the seat, got %q", test.locale, message.Short)
+		}
+	}
+
+	before := ticket("")
+	after := ticket("")
+	after.SeatSection, after.SeatNumber = pb.SeatSection_B, 12
+	message, err := templates.Render(events.SeatChanged, before, after)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(message.Body, "section A, seat 7") || !strings.Contains(message.Body, "section B, seat 12") {
+		t.Errorf("Expected the old and new seats, got:\n%s", message.Body)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	complete := `{{define "subject"}}Ticket {{.TicketID}}{{end}}{{define "body"}}Hi {{.FirstName}}{{end}}{{define "sms"}}Seat {{.Seat}}{{end}}`
+	write("de/TicketPurchased.tmpl", complete)
+	write("de/SeatChanged.tmpl", complete)
+	write("de/TicketCancelled.tmpl", complete)
+	if _, err := notify.LoadTemplates(dir); err == nil || !strings.Contains(err.Error(), `"en"`) {
+		t.Errorf("Expected templates without English to be rejected, got %v", err)
+	}
+
+	write("en/TicketPurchased.tmpl", complete)
+	write("en/SeatChanged.tmpl", complete)
+	write("en/TicketCancelled.tmpl", `{{define "subject"}}Cancelled{{end}}`)
+	if _, err := notify.LoadTemplates(dir); err == nil || !strings.Contains(err.Error(), `"body"`) {
+		t.Errorf("Expected a template missing its body to be rejected, got %v", err)
+	}
+
+	write("en/TicketCancelled.tmpl", complete)
+	templates, err := notify.LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates failed: %v", err)
+	}
+	message, err := templates.Render(events.TicketCancelled, ticket("de-AT"), nil)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if message.Locale != "de" || message.Subject != "Ticket ticket-1" || message.Short != "Seat 7" {
+		t.Errorf("Unexpected message %+v", message)
+	}
+}
+
+func TestEmailGoesToThePassenger(t *testing.T) {
+	sink := smtptest.NewServer()
+	defer sink.Close()
+	notifier := notify.NewNotifier(notify.DefaultTemplates(), []notify.Channel{
+		&notify.SMTP{Addr: sink.Addr, From: "Book My Seat <tickets@example.com>", Username: "mailer", Password: "secret"},
+	}, quiet())
+	run(t, notifier)
+
+	notifier.Notify(events.TicketPurchased, nil, ticket("fr"))
+	messages := sink.WaitForMessages(1, 5*time.Second)
+	if len(messages) != 1 {
+		t.Fatalf("Expected one email, got %d", len(messages))
+	}
+	email := messages[0]
+	if len(email.To) != 1 || email.To[0] != "amelie@example.com" || email.From != "tickets@example.com" {
+		t.Errorf("Expected an email from tickets@example.com to amelie@example.com, got %s to %v", email.From, email.To)
+	}
+	if email.Username != "mailer" {
+		t.Errorf("Expected the channel to authenticate as mailer, got %q", email.Username)
+	}
+	if email.Subject != "Votre place de London à France est réservée" {
+		t.Errorf("Unexpected subject %q", email.Subject)
+	}
+	if email.Header.Get("Content-Language") != "fr" || !strings.Contains(email.Body, "Bonjour Amélie") || !strings.Contains(email.Body, "siège 7") {
+		t.Errorf("Expected a French email, got %v:\n%s", email.Header, email.Body)
+	}
+}
+
+// smsProvider is an SMS provider answering with the queued statuses first,
+// then 200.
+type smsProvider struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	sent     []map[string]string
+}
+
+func newSMSProvider(t *testing.T, statuses ...int) *smsProvider {
+	p := &smsProvider{statuses: statuses}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if len(p.statuses) > 0 {
+			w.WriteHeader(p.statuses[0])
+			p.statuses = p.statuses[1:]
+			return
+		}
+		var message map[string]string
+		json.NewDecoder(r.Body).Decode(&message)
+		p.sent = append(p.sent, message)
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *smsProvider) waitForSent(n int) []map[string]string {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		sent := append([]map[string]string(nil), p.sent...)
+		p.mu.Unlock()
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFailedSendsAreRetried(t *testing.T) {
+	provider := newSMSProvider(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	notifier := notify.NewNotifier(notify.DefaultTemplates(), []notify.Channel{&notify.SMS{URL: provider.URL, Token: "token"}},
+		notify.WithRetryPolicy(events.RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}),
+		quiet())
+	run(t, notifier)
+
+	notifier.Notify(events.TicketCancelled, ticket(""), nil)
+	sent := provider.waitForSent(1)
+	if len(sent) != 1 || sent[0]["to"] != "+33612345678" || !strings.Contains(sent[0]["body"], "cancelled") {
+		t.Errorf("Expected the cancellation to be texted after two failures, got %v", sent)
+	}
+}
+
+func TestPermanentFailuresAreNotRetried(t *testing.T) {
+	provider := newSMSProvider(t, http.StatusBadRequest)
+	notifier := notify.NewNotifier(notify.DefaultTemplates(), []notify.Channel{&notify.SMS{URL: provider.URL, Token: "token"}},
+		notify.WithRetryPolicy(events.RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}),
+		quiet())
+	run(t, notifier)
+
+	notifier.Notify(events.TicketPurchased, nil, ticket(""))
+	time.Sleep(100 * time.Millisecond)
+	if sent := provider.waitForSent(0); len(sent) != 0 {
+		t.Errorf("Expected the rejected message not to be retried, got %v", sent)
+	}
+}
+
+func TestChannelsThatCannotReachThePassengerAreSkipped(t *testing.T) {
+	provider := newSMSProvider(t)
+	sink := smtptest.NewServer()
+	defer sink.Close()
+	notifier := notify.NewNotifier(notify.DefaultTemplates(), []notify.Channel{
+		&notify.SMS{URL: provider.URL, Token: "token"},
+		&notify.SMTP{Addr: sink.Addr, From: "tickets@example.com"},
+	}, quiet())
+	run(t, notifier)
+
+	withoutPhone := ticket("")
+	withoutPhone.User.Phone = ""
+	notifier.Notify(events.TicketPurchased, nil, withoutPhone)
+	if messages := sink.WaitForMessages(1, 5*time.Second); len(messages) != 1 {
+		t.Fatalf("Expected an email, got %d", len(messages))
+	}
+	if sent := provider.waitForSent(0); len(sent) != 0 {
+		t.Errorf("Expected no SMS without a phone number, got %v", sent)
+	}
+}
@@ -0,0 +1,238 @@
+// Package ratelimit bounds how often each caller may call each gRPC method, so
+// that a single script cannot, for example, buy up every seat in a burst of
+// calls. Every caller gets a token bucket per method; a call finding its bucket
+// empty fails with ResourceExhausted and tells the caller when to retry.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader carries, on rejected calls, the number of seconds after
+// which the call may succeed. The same delay is in the RetryInfo detail of the
+// status.
+const RetryAfterHeader = "retry-after"
+
+// ForwardedForHeader carries the addresses of the clients of a proxy, such as
+// the HTTP/JSON gateway, with the client of the proxy last.
+const ForwardedForHeader = "x-forwarded-for"
+
+// Limit is a token bucket: calls are allowed at Rate per second on average, and
+// up to Burst at once.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// Limiter rate limits calls per caller and method.
+type Limiter struct {
+	methods  map[string]Limit // by full method name or bare method name
+	fallback *Limit
+	exempt   []string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	caller string
+	method string
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+// idleBucket is how long a bucket is kept after its last use. Buckets refill
+// well within that time at any useful rate, so dropping them loses nothing.
+const idleBucket = 10 * time.Minute
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithDefault limits every method without a limit of its own.
+func WithDefault(limit Limit) Option {
+	return func(l *Limiter) {
+		l.fallback = &limit
+	}
+}
+
+// WithMethodLimit limits method, given by its full name, such as
+// "/BookingService.BookingService/PurchaseTicket", or by its bare name, such as
+// "PurchaseTicket", to match it in every service.
+func WithMethodLimit(method string, limit Limit) Option {
+	return func(l *Limiter) {
+		l.methods[method] = limit
+	}
+}
+
+// WithExemptServices leaves the methods of the given fully qualified services,
+// such as health checks, unlimited.
+func WithExemptServices(services ...string) Option {
+	return func(l *Limiter) {
+		l.exempt = append(l.exempt, services...)
+	}
+}
+
+// WithClock sets the clock buckets are filled by, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// NewLimiter returns a limiter leaving methods without a limit unlimited.
+func NewLimiter(opts ...Option) *Limiter {
+	l := &Limiter{
+		methods: make(map[string]Limit),
+		now:     time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// UnaryInterceptor rate limits unary calls. It must run after authentication,
+// so that authenticated callers are told apart by identity.
+func (l *Limiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.allow(ctx, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rate limits the opening of streams.
+func (l *Limiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.allow(stream.Context(), info.FullMethod, stream.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// allow takes a token from the bucket of the caller of ctx for method, or
+// returns a ResourceExhausted error after sending the retry delay as header
+// metadata with setHeader.
+func (l *Limiter) allow(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	limit, ok := l.limit(method)
+	if !ok {
+		return nil
+	}
+	caller := Caller(ctx)
+	if caller == "" {
+		return nil
+	}
+
+	now := l.now()
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) > idleBucket {
+		for key, b := range l.buckets {
+			if now.Sub(b.used) > idleBucket {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+	key := bucketKey{caller: caller, method: method}
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{limiter: rate.NewLimiter(limit.Rate, limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.used = now
+	reservation := b.limiter.ReserveN(now, 1)
+	var delay time.Duration
+	if !reservation.OK() {
+		delay = idleBucket
+	} else if delay = reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	seconds := int64(math.Ceil(delay.Seconds()))
+	_ = setHeader(metadata.Pairs(RetryAfterHeader, strconv.FormatInt(seconds, 10)))
+	st, err := status.New(codes.ResourceExhausted, "rate limit exceeded, retry in "+strconv.FormatInt(seconds, 10)+"s").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return st.Err()
+}
+
+// limit returns the limit of method, if it has one.
+func (l *Limiter) limit(method string) (Limit, bool) {
+	for _, service := range l.exempt {
+		if strings.HasPrefix(method, "/"+service+"/") {
+			return Limit{}, false
+		}
+	}
+	if limit, ok := l.methods[method]; ok {
+		return limit, true
+	}
+	if limit, ok := l.methods[method[strings.LastIndex(method, "/")+1:]]; ok {
+		return limit, true
+	}
+	if l.fallback != nil {
+		return *l.fallback, true
+	}
+	return Limit{}, false
+}
+
+// Caller identifies the caller of ctx: by the subject of its identity when it
+// authenticated, which for an accepted API key is the name of the key, else by
+// its IP address. An API key the authenticator has not accepted is ignored, so
+// that made-up keys cannot give one client fresh buckets. Calls relayed by an
+// in-process proxy such as the gateway are identified by the last address of
+// the x-forwarded-for header the proxy added. It returns "" when the caller
+// cannot be told, as for in-process calls.
+func Caller(ctx context.Context) string {
+	if identity, ok := auth.FromContext(ctx); ok && identity != nil {
+		return "subject:" + identity.Subject
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		return "ip:" + addr.IP.String()
+	}
+	// gRPC-Web calls carry the address of the HTTP client as a string.
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil && net.ParseIP(host) != nil {
+		return "ip:" + net.ParseIP(host).String()
+	}
+	if forwarded := md.Get(ForwardedForHeader); len(forwarded) > 0 {
+		last := forwarded[len(forwarded)-1]
+		if ip := net.ParseIP(strings.TrimSpace(last[strings.LastIndex(last, ",")+1:])); ip != nil {
+			return "ip:" + ip.String()
+		}
+	}
+	return "peer:" + p.Addr.String()
+}
@@ -0,0 +1,186 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/ratelimit"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const purchase = "/BookingService.BookingService/PurchaseTicket"
+
+// clock is a manually advanced time source.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func identity(subject string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Identity{Subject: subject})
+}
+
+// call runs method through the interceptor of limiter as the caller of ctx.
+func call(limiter *ratelimit.Limiter, ctx context.Context, method string) error {
+	_, err := limiter.UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	return err
+}
+
+func TestBucketsArePerCallerAndMethod(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	limiter := ratelimit.NewLimiter(
+		ratelimit.WithClock(c.Now),
+		ratelimit.WithMethodLimit("PurchaseTicket", ratelimit.Limit{Rate: 1, Burst: 2}),
+	)
+	pat, sam := identity("pat"), identity("sam")
+
+	for i := 0; i < 2; i++ {
+		if err := call(limiter, pat, purchase); err != nil {
+			t.Fatalf("Call %d: expected the burst to be allowed, got %v", i+1, err)
+		}
+	}
+	err := call(limiter, pat, purchase)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted once the burst is spent, got %v", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() != time.Second {
+		t.Errorf("Expected a RetryInfo of 1s, got %v", status.Convert(err).Details())
+	}
+
+	if err := call(limiter, sam, purchase); err != nil {
+		t.Errorf("Expected another caller to have a bucket of their own, got %v", err)
+	}
+	if err := call(limiter, pat, "/bookmyseat.booking.v2.BookingService/PurchaseTicket"); err != nil {
+		t.Errorf("Expected the bare name to give every service a bucket of its own, got %v", err)
+	}
+	if err := call(limiter, pat, "/BookingService.BookingService/GetReceipt"); err != nil {
+		t.Errorf("Expected methods without a limit to be unlimited, got %v", err)
+	}
+
+	// Rejected calls take no token, so one second refills exactly one.
+	c.now = c.now.Add(time.Second)
+	if err := call(limiter, pat, purchase); err != nil {
+		t.Errorf("Expected a token after a second, got %v", err)
+	}
+	if err := call(limiter, pat, purchase); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected only one token after a second, got %v", err)
+	}
+}
+
+func TestMethodLimitsOverrideTheDefault(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	limiter := ratelimit.NewLimiter(
+		ratelimit.WithClock(c.Now),
+		ratelimit.WithDefault(ratelimit.Limit{Rate: 1, Burst: 1}),
+		ratelimit.WithMethodLimit("/BookingService.BookingService/ListTickets", ratelimit.Limit{Rate: 1, Burst: 3}),
+		ratelimit.WithExemptServices("grpc.health.v1.Health"),
+	)
+	ctx := identity("pat")
+	for i := 0; i < 3; i++ {
+		if err := call(limiter, ctx, "/BookingService.BookingService/ListTickets"); err != nil {
+			t.Fatalf("Call %d: expected the method's own burst, got %v", i+1, err)
+		}
+	}
+	if err := call(limiter, ctx, purchase); err != nil {
+		t.Fatalf("Expected the default burst, got %v", err)
+	}
+	if err := call(limiter, ctx, purchase); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected the default to apply, got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := call(limiter, ctx, "/grpc.health.v1.Health/Check"); err != nil {
+			t.Fatalf("Expected health checks to be exempt, got %v", err)
+		}
+	}
+	if err := call(limiter, context.Background(), purchase); err != nil {
+		t.Errorf("Expected in-process calls to be unlimited, got %v", err)
+	}
+}
+
+func TestRetryAfterIsSentAsMetadata(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.WithMethodLimit("PurchaseTicket", ratelimit.Limit{Rate: 1.0 / 90, Burst: 1}))
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor()))
+	pb.RegisterBookingServiceServer(server, pb.UnimplementedBookingServiceServer{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := pb.NewBookingServiceClient(conn)
+
+	if _, err := client.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("Expected the first call through, got %v", err)
+	}
+	var header metadata.MD
+	_, err = client.PurchaseTicket(context.Background(), &pb.PurchaseTicketRequest{}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+	if retryAfter := header.Get(ratelimit.RetryAfterHeader); len(retryAfter) != 1 || retryAfter[0] != "90" {
+		t.Errorf("Expected to be told to retry in 90 seconds, got %v", header)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	withPeer := func(ctx context.Context, addr net.Addr) context.Context {
+		return peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	tcp := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 41000}
+	forwarded := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ratelimit.ForwardedForHeader, "198.51.100.1, 203.0.113.9"))
+	for _, test := range []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"identity", withPeer(identity("pat"), tcp), "subject:pat"},
+		{"TCP peer", withPeer(context.Background(), tcp), "ip:203.0.113.7"},
+		{"TCP peer ignores x-forwarded-for", withPeer(forwarded, tcp), "ip:203.0.113.7"},
+		{"gRPC-Web client", withPeer(context.Background(), stringAddr("203.0.113.8:52000")), "ip:203.0.113.8"},
+		{"gateway client", withPeer(forwarded, stringAddr("bufconn")), "ip:203.0.113.9"},
+		{"in-process", context.Background(), ""},
+	} {
+		if got := ratelimit.Caller(test.ctx); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+
+	key := metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.APIKeyHeader, "secret"))
+	other := metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.APIKeyHeader, "other"))
+	if got := ratelimit.Caller(withPeer(key, tcp)); got != "ip:203.0.113.7" || got != ratelimit.Caller(withPeer(other, tcp)) {
+		t.Errorf("Expected unverified API keys to be ignored, got %q", got)
+	}
+	accepted := auth.NewContext(key, &auth.Identity{Subject: "ops-tool", Method: "api-key"})
+	if got := ratelimit.Caller(withPeer(accepted, tcp)); got != "subject:ops-tool" {
+		t.Errorf("Expected an accepted API key to identify the caller, got %q", got)
+	}
+}
+
+// stringAddr is a net.Addr known only by its string, as the peers of gRPC-Web
+// and in-process calls are.
+type stringAddr string
+
+func (a stringAddr) Network() string { return "" }
+func (a stringAddr) String() string  { return string(a) }
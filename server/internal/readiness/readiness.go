@@ -0,0 +1,125 @@
+// Package readiness tells orchestrators whether the server can take traffic. It
+// serves the standard grpc.health.v1 service and rejects calls to gated services
+// until the server is ready.
+package readiness
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// HealthService is the name of the health service, which needs no credentials.
+const HealthService = "grpc.health.v1.Health"
+
+// Gate reports NOT_SERVING, overall and for each gated service, until SetReady
+// is called and again once Drain is called. Calls to gated services are
+// rejected with Unavailable while not ready.
+type Gate struct {
+	health   *health.Server
+	services []string
+
+	mu           sync.RWMutex
+	ready        bool
+	draining     chan struct{}
+	drainStarted bool
+}
+
+// NewGate returns a gate rejecting calls to the given fully qualified services.
+func NewGate(services ...string) *Gate {
+	g := &Gate{health: health.NewServer(), services: services, draining: make(chan struct{})}
+	g.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	for _, service := range services {
+		g.health.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return g
+}
+
+// Register adds the health service to server.
+func (g *Gate) Register(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, g.health)
+}
+
+// SetReady marks the server and every gated service as SERVING.
+func (g *Gate) SetReady() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.drainStarted {
+		return
+	}
+	g.ready = true
+	g.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	for _, service := range g.services {
+		g.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+}
+
+// Drain marks everything NOT_SERVING for good and ends open health watches,
+// which would otherwise keep a graceful stop waiting.
+func (g *Gate) Drain() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.drainStarted {
+		return
+	}
+	g.drainStarted = true
+	g.ready = false
+	g.health.Shutdown()
+	close(g.draining)
+}
+
+// check returns an Unavailable error for calls to a gated service while the
+// server is not ready.
+func (g *Gate) check(fullMethod string) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.ready {
+		return nil
+	}
+	for _, service := range g.services {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return status.Errorf(codes.Unavailable, "the server is not ready")
+		}
+	}
+	return nil
+}
+
+// UnaryInterceptor rejects calls to gated services while the server is not ready.
+func (g *Gate) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := g.check(info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects streams of gated services while the server is not
+// ready and cancels health watches when draining starts.
+func (g *Gate) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := g.check(info.FullMethod); err != nil {
+			return err
+		}
+		if info.FullMethod != "/"+HealthService+"/Watch" {
+			return handler(srv, stream)
+		}
+		ctx, cancel := context.WithCancel(stream.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-g.draining:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		return handler(srv, grpcutil.WithContext(stream, ctx))
+	}
+}
@@ -0,0 +1,123 @@
+package readiness_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/readiness"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var bookingService = pb.BookingService_ServiceDesc.ServiceName
+
+func serve(t *testing.T, gate *readiness.Gate) (*grpc.Server, *grpc.ClientConn) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(gate.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(gate.StreamInterceptor()),
+	)
+	pb.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceServer())
+	gate.Register(grpcServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return grpcServer, conn
+}
+
+func checkStatus(t *testing.T, health healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	response, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("Check(%q) failed: %v", service, err)
+	}
+	return response.Status
+}
+
+func TestGateLifecycle(t *testing.T) {
+	gate := readiness.NewGate(bookingService)
+	_, conn := serve(t, gate)
+	health := healthpb.NewHealthClient(conn)
+	client := pb.NewBookingServiceClient(conn)
+	ctx := context.Background()
+
+	for _, service := range []string{"", bookingService} {
+		if got := checkStatus(t, health, service); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Expected %q to be NOT_SERVING before the bookings are loaded, got %v", service, got)
+		}
+	}
+	if _, err := client.ListTickets(ctx, &pb.ListTicketsRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable before the server is ready, got %v", err)
+	}
+	stream, err := client.ExportManifest(ctx, &pb.ExportManifestRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable for a stream before the server is ready, got %v", err)
+	}
+
+	gate.SetReady()
+	for _, service := range []string{"", bookingService} {
+		if got := checkStatus(t, health, service); got != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected %q to be SERVING once ready, got %v", service, got)
+		}
+	}
+	if _, err := client.ListTickets(ctx, &pb.ListTicketsRequest{}); err != nil {
+		t.Errorf("Expected calls to succeed once ready, got %v", err)
+	}
+	if _, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown.Service"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown service, got %v", err)
+	}
+
+	gate.Drain()
+	gate.SetReady()
+	if got := checkStatus(t, health, bookingService); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING while draining, got %v", got)
+	}
+	if _, err := client.ListTickets(ctx, &pb.ListTicketsRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable while draining, got %v", err)
+	}
+}
+
+func TestDrainEndsWatches(t *testing.T) {
+	gate := readiness.NewGate(bookingService)
+	grpcServer, conn := serve(t, gate)
+	gate.SetReady()
+
+	watch, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{Service: bookingService})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if response, err := watch.Recv(); err != nil || response.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING, got %v, %v", response, err)
+	}
+
+	gate.Drain()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("An open health watch kept the graceful stop waiting")
+	}
+}
@@ -0,0 +1,150 @@
+// Package render turns bookings into documents meant for people: passenger
+// manifests for conductors and receipts for passengers.
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Manifest lists the passengers of one departure. Tickets are rendered in the
+// order given, which callers are expected to have sorted by section and seat.
+type Manifest struct {
+	From        string
+	To          string
+	GeneratedAt time.Time
+	Tickets     []*pb.Ticket
+}
+
+// ManifestContentType returns the MIME type of a manifest rendered in format.
+func ManifestContentType(format pb.ManifestFormat) string {
+	switch format {
+	case pb.ManifestFormat_MANIFEST_FORMAT_JSON:
+		return "application/json"
+	case pb.ManifestFormat_MANIFEST_FORMAT_PDF:
+		return "application/pdf"
+	default:
+		return "text/csv"
+	}
+}
+
+// Write renders the manifest to w in the requested format.
+func (m *Manifest) Write(w io.Writer, format pb.ManifestFormat) error {
+	switch format {
+	case pb.ManifestFormat_MANIFEST_FORMAT_CSV:
+		return m.writeCSV(w)
+	case pb.ManifestFormat_MANIFEST_FORMAT_JSON:
+		return m.writeJSON(w)
+	case pb.ManifestFormat_MANIFEST_FORMAT_PDF:
+		return m.writePDF(w)
+	default:
+		return fmt.Errorf("unsupported manifest format %v", format)
+	}
+}
+
+var manifestColumns = []string{"seat_section", "seat_number", "first_name", "last_name", "email", "price_paid"}
+
+func manifestRow(ticket *pb.Ticket) []string {
+	return []string{
+		ticket.SeatSection.String(),
+		strconv.FormatUint(uint64(ticket.SeatNumber), 10),
+		ticket.User.GetFirstName(),
+		ticket.User.GetLastName(),
+		ticket.User.GetEmail(),
+		strconv.FormatFloat(float64(ticket.PricePaid), 'f', 2, 32),
+	}
+}
+
+func (m *Manifest) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(manifestColumns); err != nil {
+		return err
+	}
+	for _, ticket := range m.Tickets {
+		if err := writer.Write(manifestRow(ticket)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type manifestPassenger struct {
+	SeatSection string  `json:"seat_section"`
+	SeatNumber  uint32  `json:"seat_number"`
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	Email       string  `json:"email"`
+	PricePaid   float32 `json:"price_paid"`
+}
+
+func (m *Manifest) writeJSON(w io.Writer) error {
+	document := struct {
+		From        string              `json:"from"`
+		To          string              `json:"to"`
+		GeneratedAt time.Time           `json:"generated_at"`
+		Passengers  []manifestPassenger `json:"passengers"`
+	}{From: m.From, To: m.To, GeneratedAt: m.GeneratedAt, Passengers: make([]manifestPassenger, 0, len(m.Tickets))}
+	for _, ticket := range m.Tickets {
+		document.Passengers = append(document.Passengers, manifestPassenger{
+			SeatSection: ticket.SeatSection.String(),
+			SeatNumber:  ticket.SeatNumber,
+			FirstName:   ticket.User.GetFirstName(),
+			LastName:    ticket.User.GetLastName(),
+			Email:       ticket.User.GetEmail(),
+			PricePaid:   ticket.PricePaid,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func (m *Manifest) writePDF(w io.Writer) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Passenger manifest %s - %s", m.From, m.To), true)
+	widths := []float64{22, 18, 35, 35, 60, 20}
+	header := []string{"Section", "Seat", "First name", "Last name", "Email", "Price"}
+	printHeader := func() {
+		pdf.SetFont("Helvetica", "B", 10)
+		for i, title := range header {
+			pdf.CellFormat(widths[i], 7, title, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 10)
+	}
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Passenger manifest: %s to %s", m.From, m.To), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 6, fmt.Sprintf("Generated %s, %d passengers", m.GeneratedAt.UTC().Format(time.RFC1123), len(m.Tickets)), "", 1, "L", false, 0, "")
+		pdf.Ln(2)
+		printHeader()
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	for _, ticket := range m.Tickets {
+		for i, value := range manifestRow(ticket) {
+			align := "L"
+			if i == 1 || i == 5 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, translate(value), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	return pdf.Output(w)
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	textTemplate "text/template"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// Receipt is a passenger's proof of purchase. Payload is the signed ticket
+// payload encoded in the e-ticket's QR code.
+type Receipt struct {
+	Ticket  *pb.Ticket
+	Payload string
+}
+
+// ReceiptContentType returns the MIME type and file extension of a receipt rendered in format.
+func ReceiptContentType(format pb.ReceiptFormat) (string, string) {
+	switch format {
+	case pb.ReceiptFormat_RECEIPT_FORMAT_HTML:
+		return "text/html; charset=utf-8", "html"
+	case pb.ReceiptFormat_RECEIPT_FORMAT_PDF, pb.ReceiptFormat_RECEIPT_FORMAT_E_TICKET:
+		return "application/pdf", "pdf"
+	default:
+		return "text/plain; charset=utf-8", "txt"
+	}
+}
+
+// Write renders the receipt to w in the requested format.
+func (r *Receipt) Write(w io.Writer, format pb.ReceiptFormat) error {
+	switch format {
+	case pb.ReceiptFormat_RECEIPT_FORMAT_TEXT:
+		return textReceipt.Execute(w, r.fields())
+	case pb.ReceiptFormat_RECEIPT_FORMAT_HTML:
+		return r.writeHTML(w)
+	case pb.ReceiptFormat_RECEIPT_FORMAT_PDF:
+		return r.writePDF(w, false)
+	case pb.ReceiptFormat_RECEIPT_FORMAT_E_TICKET:
+		return r.writePDF(w, true)
+	default:
+		return fmt.Errorf("unsupported receipt format %v", format)
+	}
+}
+
+type receiptFields struct {
+	TicketID  string
+	Passenger string
+	Email     string
+	From      string
+	To        string
+	Seat      string
+	Price     string
+	QRCode    template.URL
+}
+
+func (r *Receipt) fields() receiptFields {
+	ticket := r.Ticket
+	return receiptFields{
+		TicketID:  ticket.Id,
+		Passenger: ticket.User.GetFirstName() + " " + ticket.User.GetLastName(),
+		Email:     ticket.User.GetEmail(),
+		From:      ticket.From,
+		To:        ticket.To,
+		Seat:      fmt.Sprintf("Section %s, seat %d", ticket.SeatSection, ticket.SeatNumber),
+		Price:     fmt.Sprintf("$%.2f", ticket.PricePaid),
+	}
+}
+
+var textReceipt = textTemplate.Must(textTemplate.New("receipt").Parse(`BOOK MY SEAT - RECEIPT
+======================
+
+Ticket     : {{.TicketID}}
+Passenger  : {{.Passenger}}
+Email      : {{.Email}}
+From       : {{.From}}
+To         : {{.To}}
+Seat       : {{.Seat}}
+Price paid : {{.Price}}
+`))
+
+var htmlReceipt = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.TicketID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 32em; margin: 2em auto; }
+th { text-align: left; padding-right: 1em; }
+</style>
+</head>
+<body>
+<h1>Book My Seat receipt</h1>
+<table>
+<tr><th>Ticket</th><td>{{.TicketID}}</td></tr>
+<tr><th>Passenger</th><td>{{.Passenger}}</td></tr>
+<tr><th>Email</th><td>{{.Email}}</td></tr>
+<tr><th>From</th><td>{{.From}}</td></tr>
+<tr><th>To</th><td>{{.To}}</td></tr>
+<tr><th>Seat</th><td>{{.Seat}}</td></tr>
+<tr><th>Price paid</th><td>{{.Price}}</td></tr>
+</table>
+{{if .QRCode}}<p><img src="{{.QRCode}}" alt="Ticket QR code" width="256" height="256"></p>{{end}}
+</body>
+</html>
+`))
+
+func (r *Receipt) writeHTML(w io.Writer) error {
+	fields := r.fields()
+	if r.Payload != "" {
+		png, err := qrcode.Encode(r.Payload, qrcode.Medium, 256)
+		if err != nil {
+			return fmt.Errorf("encoding QR code: %v", err)
+		}
+		fields.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+	}
+	return htmlReceipt.Execute(w, fields)
+}
+
+// writePDF renders a one page receipt, adding the QR code of the signed ticket
+// payload when eTicket is set.
+func (r *Receipt) writePDF(w io.Writer, eTicket bool) error {
+	fields := r.fields()
+	title := "Receipt"
+	if eTicket {
+		title = "E-ticket"
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("%s %s", title, fields.TicketID), true)
+	pdf.AddPage()
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 12, "Book My Seat - "+title, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	rows := [][2]string{
+		{"Ticket", fields.TicketID},
+		{"Passenger", fields.Passenger},
+		{"Email", fields.Email},
+		{"From", fields.From},
+		{"To", fields.To},
+		{"Seat", fields.Seat},
+		{"Price paid", fields.Price},
+	}
+	for _, row := range rows {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(35, 8, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 8, translate(row[1]), "", 1, "L", false, 0, "")
+	}
+
+	if eTicket {
+		if r.Payload == "" {
+			return fmt.Errorf("an e-ticket needs a signed ticket payload")
+		}
+		code, err := qrcode.New(r.Payload, qrcode.Medium)
+		if err != nil {
+			return fmt.Errorf("encoding QR code: %v", err)
+		}
+		drawQRCode(pdf, code.Bitmap(), 20, pdf.GetY()+10, 70)
+	}
+
+	return pdf.Output(w)
+}
+
+// drawQRCode draws bitmap as filled squares in a size x size mm box at (x, y).
+func drawQRCode(pdf *gofpdf.Fpdf, bitmap [][]bool, x, y, size float64) {
+	module := size / float64(len(bitmap))
+	pdf.SetFillColor(0, 0, 0)
+	for row, modules := range bitmap {
+		for col, dark := range modules {
+			if dark {
+				pdf.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
+		}
+	}
+}
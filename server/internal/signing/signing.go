@@ -0,0 +1,149 @@
+// Package signing produces tamper-evident ticket payloads. A payload carries the
+// canonical fields of a ticket together with an Ed25519 signature by the server,
+// so anyone holding the public key can tell a genuine ticket from a forged one.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+)
+
+// payloadPrefix versions the payload encoding.
+const payloadPrefix = "BMS1"
+
+// Claims are the canonical ticket fields covered by a signature.
+type Claims struct {
+	TicketID    string  `json:"id"`
+	Email       string  `json:"email"`
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	SeatSection string  `json:"section"`
+	SeatNumber  uint32  `json:"seat"`
+	PricePaid   float32 `json:"price"`
+}
+
+// ClaimsOf extracts the signed fields of ticket.
+func ClaimsOf(ticket *pb.Ticket) Claims {
+	return Claims{
+		TicketID:    ticket.Id,
+		Email:       ticket.User.GetEmail(),
+		FirstName:   ticket.User.GetFirstName(),
+		LastName:    ticket.User.GetLastName(),
+		From:        ticket.From,
+		To:          ticket.To,
+		SeatSection: ticket.SeatSection.String(),
+		SeatNumber:  ticket.SeatNumber,
+		PricePaid:   ticket.PricePaid,
+	}
+}
+
+// canonical returns the byte string that is signed. encoding/json writes struct
+// fields in declaration order, so equal claims always encode identically.
+func (c Claims) canonical() []byte {
+	raw, _ := json.Marshal(c)
+	return raw
+}
+
+// Signer signs and verifies ticket payloads with an Ed25519 key pair.
+type Signer struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewSigner returns a Signer using key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{private: key, public: key.Public().(ed25519.PublicKey)}
+}
+
+// GenerateSigner returns a Signer with a fresh random key. Tickets it signs
+// cannot be verified once the process exits.
+func GenerateSigner() (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating signing key: %v", err)
+	}
+	return NewSigner(key), nil
+}
+
+// LoadSigner reads a PEM encoded PKCS #8 Ed25519 private key, as written by
+// "openssl genpkey -algorithm ed25519", from path.
+func LoadSigner(path string) (*Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %v", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded private key", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key: %v", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s does not contain an Ed25519 key", path)
+	}
+	return NewSigner(edKey), nil
+}
+
+// PublicKey returns the key that verifies this signer's signatures.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.public
+}
+
+// Sign sets the signature of ticket over its current claims. Tickets must be
+// signed again whenever a signed field, such as the seat, changes.
+func (s *Signer) Sign(ticket *pb.Ticket) {
+	ticket.Signature = ed25519.Sign(s.private, ClaimsOf(ticket).canonical())
+}
+
+// VerifyTicket checks that the signature of ticket matches its claims.
+func (s *Signer) VerifyTicket(ticket *pb.Ticket) error {
+	if !ed25519.Verify(s.public, ClaimsOf(ticket).canonical(), ticket.Signature) {
+		return fmt.Errorf("invalid ticket signature")
+	}
+	return nil
+}
+
+// Payload encodes the claims of a signed ticket and its signature into a
+// compact, URL-safe string suitable for QR codes: BMS1.<claims>.<signature>.
+func Payload(ticket *pb.Ticket) string {
+	claims := ClaimsOf(ticket).canonical()
+	return payloadPrefix + "." + base64.RawURLEncoding.EncodeToString(claims) + "." + base64.RawURLEncoding.EncodeToString(ticket.Signature)
+}
+
+// Verify decodes payload and returns its claims if the signature is valid.
+func (s *Signer) Verify(payload string) (Claims, error) {
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 || parts[0] != payloadPrefix {
+		return Claims{}, fmt.Errorf("malformed ticket payload")
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, fmt.Errorf("malformed ticket payload")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("malformed ticket payload")
+	}
+	if !ed25519.Verify(s.public, claims, signature) {
+		return Claims{}, fmt.Errorf("invalid ticket signature")
+	}
+	var decoded Claims
+	if err := json.Unmarshal(claims, &decoded); err != nil {
+		return Claims{}, fmt.Errorf("malformed ticket payload")
+	}
+	return decoded, nil
+}
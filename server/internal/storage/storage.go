@@ -0,0 +1,164 @@
+// Package storage keeps the server's bookings across restarts. The server holds
+// every booking in memory and hands a Store a complete snapshot to save; on
+// startup it loads the last snapshot back.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Snapshot is the complete state of the bookings.
+type Snapshot struct {
+	// Tickets are keyed by normalized email.
+	Tickets map[string]*pb.Ticket
+	// Buyers maps the IDs of tickets to the callers that bought them, for
+	// tickets bought by callers that could be told apart.
+	Buyers map[string]string
+	// Cancelled lists the IDs of removed tickets, so that their signed payloads
+	// keep verifying as cancelled rather than forged.
+	Cancelled []string
+	// Audit is the audit log, oldest event first.
+	Audit []*pb.AuditEvent
+	// Outbox lists the event deliveries still pending, DeadLetters those that
+	// were given up on.
+	Outbox      []*events.Delivery
+	DeadLetters []*events.Delivery
+}
+
+// Store saves and loads snapshots.
+type Store interface {
+	// Load returns the last saved snapshot, or an empty one if nothing was saved yet.
+	Load(ctx context.Context) (*Snapshot, error)
+	// Save replaces the stored snapshot.
+	Save(ctx context.Context, snapshot *Snapshot) error
+}
+
+// Memory is a Store that keeps nothing: bookings are lost when the server stops.
+type Memory struct{}
+
+func (Memory) Load(ctx context.Context) (*Snapshot, error) {
+	return &Snapshot{Tickets: make(map[string]*pb.Ticket)}, nil
+}
+
+func (Memory) Save(ctx context.Context, snapshot *Snapshot) error {
+	return nil
+}
+
+// File is a Store that writes snapshots as JSON to a single file. Saves write
+// a temporary file and rename it over the previous snapshot, so a crash never
+// leaves a half written file behind.
+type File struct {
+	path string
+}
+
+// NewFile returns a Store saving to path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// fileFormatVersion is bumped whenever the snapshot file layout changes.
+const fileFormatVersion = 1
+
+type snapshotFile struct {
+	Version     int                        `json:"version"`
+	Tickets     map[string]json.RawMessage `json:"tickets"`
+	Buyers      map[string]string          `json:"buyers,omitempty"`
+	Cancelled   []string                   `json:"cancelled,omitempty"`
+	Audit       []json.RawMessage          `json:"audit,omitempty"`
+	Outbox      []*events.Delivery         `json:"outbox,omitempty"`
+	DeadLetters []*events.Delivery         `json:"dead_letters,omitempty"`
+}
+
+func (f *File) Load(ctx context.Context) (*Snapshot, error) {
+	raw, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return Memory{}.Load(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file snapshotFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", f.path, err)
+	}
+	if file.Version != fileFormatVersion {
+		return nil, fmt.Errorf("%s has unsupported version %d", f.path, file.Version)
+	}
+	snapshot := &Snapshot{
+		Tickets:     make(map[string]*pb.Ticket, len(file.Tickets)),
+		Buyers:      file.Buyers,
+		Cancelled:   file.Cancelled,
+		Outbox:      file.Outbox,
+		DeadLetters: file.DeadLetters,
+	}
+	for key, message := range file.Tickets {
+		ticket := &pb.Ticket{}
+		if err := protojson.Unmarshal(message, ticket); err != nil {
+			return nil, fmt.Errorf("parsing ticket of %s in %s: %v", key, f.path, err)
+		}
+		snapshot.Tickets[key] = ticket
+	}
+	for i, message := range file.Audit {
+		event := &pb.AuditEvent{}
+		if err := protojson.Unmarshal(message, event); err != nil {
+			return nil, fmt.Errorf("parsing audit event %d in %s: %v", i+1, f.path, err)
+		}
+		snapshot.Audit = append(snapshot.Audit, event)
+	}
+	return snapshot, nil
+}
+
+func (f *File) Save(ctx context.Context, snapshot *Snapshot) error {
+	file := snapshotFile{
+		Version:     fileFormatVersion,
+		Tickets:     make(map[string]json.RawMessage, len(snapshot.Tickets)),
+		Buyers:      snapshot.Buyers,
+		Cancelled:   snapshot.Cancelled,
+		Outbox:      snapshot.Outbox,
+		DeadLetters: snapshot.DeadLetters,
+	}
+	for key, ticket := range snapshot.Tickets {
+		message, err := protojson.Marshal(ticket)
+		if err != nil {
+			return err
+		}
+		file.Tickets[key] = message
+	}
+	for _, event := range snapshot.Audit {
+		message, err := protojson.Marshal(event)
+		if err != nil {
+			return err
+		}
+		file.Audit = append(file.Audit, message)
+	}
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(raw); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), f.path)
+}
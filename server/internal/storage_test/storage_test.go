@@ -0,0 +1,72 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewFile(filepath.Join(t.TempDir(), "bookings.json"))
+
+	empty, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load of a missing file failed: %v", err)
+	}
+	if len(empty.Tickets) != 0 {
+		t.Errorf("Expected no tickets before the first save, got %d", len(empty.Tickets))
+	}
+
+	ticket := &pb.Ticket{
+		Id:          "ticket-1",
+		From:        "London",
+		To:          "France",
+		User:        &pb.User{Id: 7, FirstName: "John", LastName: "Doe", Email: "John.Doe@example.com"},
+		PricePaid:   20,
+		SeatSection: pb.SeatSection_B,
+		SeatNumber:  12,
+		Signature:   []byte{1, 2, 3},
+		BoardedAt:   timestamppb.Now(),
+	}
+	saved := &storage.Snapshot{
+		Tickets:   map[string]*pb.Ticket{"john.doe@example.com": ticket},
+		Cancelled: []string{"ticket-0"},
+	}
+	if err := store.Save(ctx, saved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !proto.Equal(loaded.Tickets["john.doe@example.com"], ticket) {
+		t.Errorf("Expected %v, got %v", ticket, loaded.Tickets["john.doe@example.com"])
+	}
+	if len(loaded.Cancelled) != 1 || loaded.Cancelled[0] != "ticket-0" {
+		t.Errorf("Expected the cancelled ticket IDs to round trip, got %v", loaded.Cancelled)
+	}
+}
+
+func TestFileRejectsCorruptSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"garbage.json": "{",
+		"version.json": `{"version": 99, "tickets": {}}`,
+		"ticket.json":  `{"version": 1, "tickets": {"a@example.com": {"seatNumber": "many"}}}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatalf("Writing %s failed: %v", name, err)
+		}
+		if _, err := storage.NewFile(path).Load(context.Background()); err == nil {
+			t.Errorf("Expected loading %s to fail", name)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package tlsutil builds the server's TLS configuration. Certificates are read
+// from files and re-read whenever the files change, so rotated certificates are
+// picked up without restarting the server.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Config describes where the server's certificates live.
+type Config struct {
+	// CertFile and KeyFile hold the PEM encoded server certificate chain and key.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile, if set, holds the CAs that client certificates are verified
+	// against. Clients may then authenticate with a certificate.
+	ClientCAFile string
+	// RequireClientCert rejects connections without a valid client certificate.
+	RequireClientCert bool
+	// Logger is where failed reloads are logged, slog.Default() if nil.
+	Logger *slog.Logger
+}
+
+// Reloader serves the certificates named by a Config, reloading them when the
+// modification time of any of the files changes.
+type Reloader struct {
+	config Config
+
+	mu          sync.Mutex
+	modified    map[string]time.Time // as of the last load attempt
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+// NewReloader loads the certificates named by config, failing if any of them is
+// missing or invalid.
+func NewReloader(config Config) (*Reloader, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, fmt.Errorf("both a certificate and a key file are required")
+	}
+	if config.RequireClientCert && config.ClientCAFile == "" {
+		return nil, fmt.Errorf("requiring client certificates needs a client CA file")
+	}
+	r := &Reloader{config: config}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+// load reads every file. The modification times of the files are recorded even
+// if loading fails, so that the same failure is not retried until a file
+// changes again. Callers must hold r.mu, except during construction.
+func (r *Reloader) load() error {
+	modified := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modified[file] = info.ModTime()
+	}
+	r.modified = modified
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("loading server certificate: %v", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		clientCAs, err = loadCertPool(r.config.ClientCAFile)
+		if err != nil {
+			return err
+		}
+	}
+	r.certificate, r.clientCAs = &certificate, clientCAs
+	return nil
+}
+
+// current returns the loaded certificates, reloading them first if a file has
+// changed. A failed reload is logged and keeps serving the previous
+// certificates, since a rotation may be observed half-way through.
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err == nil && !info.ModTime().Equal(r.modified[file]) {
+			if err := r.load(); err != nil {
+				r.logger().Error("reloading TLS certificates failed, serving the previous ones",
+					"certificate", r.config.CertFile, "error", err)
+			}
+			break
+		}
+	}
+	return r.certificate, r.clientCAs
+}
+
+func (r *Reloader) logger() *slog.Logger {
+	if r.config.Logger != nil {
+		return r.config.Logger
+	}
+	return slog.Default()
+}
+
+// TLSConfig returns a server configuration that consults the reloader on every handshake.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			certificate, clientCAs := r.current()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*certificate},
+				NextProtos:   []string{"h2"},
+			}
+			if clientCAs != nil {
+				config.ClientCAs = clientCAs
+				config.ClientAuth = tls.VerifyClientCertIfGiven
+				if r.config.RequireClientCert {
+					config.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+			return config, nil
+		},
+	}
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,252 @@
+package tlsutil_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/tlsutil"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// authority is a throwaway CA issuing certificates for the tests.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating CA key failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Creating CA certificate failed: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for subject, usable by servers for
+// localhost when server is set and by clients otherwise.
+func (a *authority) issue(t *testing.T, serial int64, subject pkix.Name, server bool) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating key failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatalf("Creating certificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Encoding key failed: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, contents []byte, modified time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		t.Fatalf("Writing %s failed: %v", path, err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatalf("Touching %s failed: %v", path, err)
+	}
+}
+
+// serve starts a TLS server using reloader with client certificate authentication
+// and the booking policy, returning its address.
+func serve(t *testing.T, reloader *tlsutil.Reloader) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	authenticator := auth.NewAuthenticator(auth.WithClientCertificates(), auth.WithPolicy(api.Policy))
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(reloader.TLSConfig())),
+		grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()),
+	)
+	pb.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceServer())
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	return listener.Addr().String()
+}
+
+func dial(t *testing.T, address string, config *tls.Config) pb.BookingServiceClient {
+	t.Helper()
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBookingServiceClient(conn)
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	now := time.Now()
+	serverCert, serverKey := ca.issue(t, 2, pkix.Name{CommonName: "book-my-seat"}, true)
+	writeFile(t, filepath.Join(dir, "server.pem"), serverCert, now)
+	writeFile(t, filepath.Join(dir, "server.key"), serverKey, now)
+	writeFile(t, filepath.Join(dir, "ca.pem"), ca.pem, now)
+
+	reloader, err := tlsutil.NewReloader(tlsutil.Config{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	})
+	if err != nil {
+		t.Fatalf("NewReloader failed: %v", err)
+	}
+	address := serve(t, reloader)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+
+	adminCert, adminKey := ca.issue(t, 3, pkix.Name{CommonName: "ops-tool", OrganizationalUnit: []string{auth.RoleAdmin}}, false)
+	certificate, err := tls.X509KeyPair(adminCert, adminKey)
+	if err != nil {
+		t.Fatalf("Loading client certificate failed: %v", err)
+	}
+	admin := dial(t, address, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{certificate}})
+	if _, err := admin.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "nobody@example.com"}); status.Code(err) == codes.Unauthenticated || status.Code(err) == codes.PermissionDenied {
+		t.Errorf("Expected the admin certificate to be accepted, got %v", err)
+	}
+
+	anonymous := dial(t, address, &tls.Config{RootCAs: roots})
+	if _, err := anonymous.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "nobody@example.com"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a client certificate, got %v", err)
+	}
+
+	untrusted := dial(t, address, &tls.Config{RootCAs: x509.NewCertPool()})
+	if _, err := untrusted.RemoveUser(context.Background(), &pb.RemoveUserRequest{Email: "nobody@example.com"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable when the server certificate is not trusted, got %v", err)
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	if _, err := tlsutil.NewReloader(tlsutil.Config{CertFile: "a", KeyFile: "b", RequireClientCert: true}); err == nil {
+		t.Errorf("Expected requiring client certificates without a CA to fail")
+	}
+
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	now := time.Now()
+	serverCert, serverKey := ca.issue(t, 2, pkix.Name{CommonName: "book-my-seat"}, true)
+	writeFile(t, filepath.Join(dir, "server.pem"), serverCert, now)
+	writeFile(t, filepath.Join(dir, "server.key"), serverKey, now)
+	writeFile(t, filepath.Join(dir, "ca.pem"), ca.pem, now)
+	reloader, err := tlsutil.NewReloader(tlsutil.Config{
+		CertFile:          filepath.Join(dir, "server.pem"),
+		KeyFile:           filepath.Join(dir, "server.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.pem"),
+		RequireClientCert: true,
+	})
+	if err != nil {
+		t.Fatalf("NewReloader failed: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+	client := dial(t, serve(t, reloader), &tls.Config{RootCAs: roots})
+	if _, err := client.GetReceipt(context.Background(), &pb.GetReceiptRequest{Email: "john@example.com"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected the handshake to fail without a client certificate, got %v", err)
+	}
+}
+
+func TestReloadRotatedCertificate(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	first := time.Now().Add(-time.Minute)
+	cert, key := ca.issue(t, 10, pkix.Name{CommonName: "first"}, true)
+	writeFile(t, certPath, cert, first)
+	writeFile(t, keyPath, key, first)
+
+	var logged bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logged, nil))
+	reloader, err := tlsutil.NewReloader(tlsutil.Config{CertFile: certPath, KeyFile: keyPath, Logger: logger})
+	if err != nil {
+		t.Fatalf("NewReloader failed: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+	address := serve(t, reloader)
+
+	serverName := func() string {
+		t.Helper()
+		conn, err := tls.Dial("tcp", address, &tls.Config{RootCAs: roots, NextProtos: []string{"h2"}})
+		if err != nil {
+			t.Fatalf("Handshake failed: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	if name := serverName(); name != "first" {
+		t.Fatalf("Expected the first certificate, got %q", name)
+	}
+
+	// A half-written rotation keeps the previous certificate in service.
+	writeFile(t, certPath, []byte("not a certificate"), time.Now())
+	if name := serverName(); name != "first" {
+		t.Fatalf("Expected the first certificate while the rotation is incomplete, got %q", name)
+	}
+	serverName()
+	if failures := strings.Count(logged.String(), "reloading TLS certificates failed"); failures != 1 {
+		t.Errorf("Expected the failed reload to be logged once, got %d times:\n%s", failures, logged.String())
+	}
+
+	cert, key = ca.issue(t, 11, pkix.Name{CommonName: "second"}, true)
+	rotated := time.Now().Add(time.Second)
+	writeFile(t, certPath, cert, rotated)
+	writeFile(t, keyPath, key, rotated)
+	if name := serverName(); name != "second" {
+		t.Errorf("Expected the rotated certificate, got %q", name)
+	}
+}
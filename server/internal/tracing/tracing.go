@@ -0,0 +1,87 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported to stdout or
+// to an OTLP collector, and trace context travels in the W3C traceparent and
+// baggage metadata of gRPC calls.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporters accepted by Config.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config selects where spans are exported.
+type Config struct {
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// Exporter is ExporterNone, ExporterStdout or ExporterOTLP.
+	Exporter string
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Endpoint string
+	// Insecure connects to the collector without TLS.
+	Insecure bool
+	// SampleRatio is the fraction of new traces recorded. Calls continuing a
+	// trace follow the sampling decision of their caller.
+	SampleRatio float64
+	// Output receives the spans of the stdout exporter, os.Stdout when nil.
+	Output io.Writer
+}
+
+// Setup installs the global tracer provider and propagator described by cfg.
+// The returned function flushes pending spans and must be called on exit. With
+// ExporterNone only the propagator is installed, so trace context still passes
+// through the server.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		output := cfg.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(output))
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %v", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,119 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/tracing"
+	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// exportedSpan is the part of a span written by the stdout exporter that the
+// tests look at.
+type exportedSpan struct {
+	Name        string
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ TraceID, SpanID string }
+	Status      struct{ Code string }
+}
+
+func decodeSpans(t *testing.T, output *bytes.Buffer) map[string][]exportedSpan {
+	t.Helper()
+	spans := map[string][]exportedSpan{}
+	decoder := json.NewDecoder(output)
+	for {
+		var span exportedSpan
+		if err := decoder.Decode(&span); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Decoding spans failed: %v", err)
+		}
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+	return spans
+}
+
+func TestSpansFollowThePurchase(t *testing.T) {
+	output := &bytes.Buffer{}
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "book-my-seat-test",
+		Exporter:    tracing.ExporterStdout,
+		SampleRatio: 1,
+		Output:      output,
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	bookingServer := api.NewBookingServiceServer()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	pb.RegisterBookingServiceServer(grpcServer, bookingServer)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewBookingServiceClient(conn)
+
+	request := &pb.PurchaseTicketRequest{
+		User:        &pb.User{FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"},
+		SeatSection: pb.SeatSection_A,
+		SeatNumber:  1,
+		TicketPrice: 20,
+	}
+	if _, err := client.PurchaseTicket(context.Background(), request); err != nil {
+		t.Fatalf("PurchaseTicket failed: %v", err)
+	}
+	if err := bookingServer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutting tracing down failed: %v", err)
+	}
+
+	spans := decodeSpans(t, output)
+	// The client and the server each record a span named after the method, the
+	// server's first as it ends before the client's.
+	rpc := spans["BookingService.BookingService/PurchaseTicket"]
+	if len(rpc) != 2 {
+		t.Fatalf("Expected a client and a server span for the call, got %v", spans)
+	}
+	serverSpan, clientSpan := rpc[0], rpc[1]
+	if serverSpan.Parent.SpanID != clientSpan.SpanContext.SpanID || serverSpan.SpanContext.TraceID != clientSpan.SpanContext.TraceID {
+		t.Errorf("Expected the trace context to travel from the client to the server, got %+v and %+v", clientSpan, serverSpan)
+	}
+	allocate := spans["allocateSeat"]
+	if len(allocate) != 1 {
+		t.Fatalf("Expected a seat allocation span, got %v", spans)
+	}
+	if allocate[0].Parent.SpanID != serverSpan.SpanContext.SpanID {
+		t.Errorf("Expected the allocation to be a child of the server span")
+	}
+	if allocate[0].Status.Code == "Error" {
+		t.Errorf("Expected the allocation to succeed")
+	}
+	if len(spans["storage.Save"]) != 1 {
+		t.Errorf("Expected a span for saving the bookings, got %v", spans)
+	}
+}
+
+func TestUnknownExporter(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "jaeger"}); err == nil {
+		t.Errorf("Expected an unknown exporter to be rejected")
+	}
+}
@@ -1,25 +1,512 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/apidesc"
 	api "github.com/KhetwalDevesh/book-my-seat/server/internal/apis"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/auth"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/config"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/events"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/gateway"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/logging"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/metrics"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/notify"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/ratelimit"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/readiness"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/signing"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/storage"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/tlsutil"
+	"github.com/KhetwalDevesh/book-my-seat/server/internal/tracing"
 	pb "github.com/KhetwalDevesh/book-my-seat/stubs/booking-service/v1"
+	bookingv2 "github.com/KhetwalDevesh/book-my-seat/stubs/bookmyseat/booking/v2"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	xrate "golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
-var address = "0.0.0.0:50051"
+// setUpLogging routes the log package, and everything else using the default
+// slog logger, through a handler with the configured level and format.
+func setUpLogging(cfg config.Log) {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.Level))
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, options)
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	}
+	slog.SetDefault(slog.New(handler))
+}
 
-func main() {
-	listen, err := net.Listen("tcp", address)
+// serviceOptions configures the booking service itself. It always passes a
+// ticket signer, so that failing to create one is reported here rather than
+// by NewBookingServiceServer.
+func serviceOptions(cfg *config.Config) ([]api.Option, error) {
+	opts := []api.Option{
+		api.WithLayout(api.Layout{From: cfg.Layout.From, To: cfg.Layout.To, SeatsPerSection: uint32(cfg.Layout.SeatsPerSection)}),
+		api.WithLimits(api.Limits{
+			MaxImportRows:       cfg.Limits.MaxImportRows,
+			MaxPageSize:         cfg.Limits.MaxPageSize,
+			MaxTicketsPerCaller: cfg.RateLimit.MaxTicketsPerCaller,
+		}),
+	}
+	var signer *signing.Signer
+	var err error
+	if cfg.SigningKey != "" {
+		if signer, err = signing.LoadSigner(cfg.SigningKey); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Printf("No signing key configured, tickets are signed with a temporary key and cannot be verified after a restart\n")
+		if signer, err = signing.GenerateSigner(); err != nil {
+			return nil, err
+		}
+	}
+	opts = append(opts, api.WithTicketSigner(signer))
+	if cfg.Storage.Backend == "file" {
+		opts = append(opts, api.WithStore(storage.NewFile(cfg.Storage.Path)))
+	}
+	return opts, nil
+}
+
+// serverTLS returns the TLS configuration of the gRPC server and the gateway,
+// or nil when they serve plaintext.
+func serverTLS(cfg *config.Config) *tls.Config {
+	if cfg.TLS.Cert == "" {
+		log.Printf("No TLS certificate configured, serving plaintext\n")
+		return nil
+	}
+	reloader, err := tlsutil.NewReloader(tlsutil.Config{
+		CertFile:          cfg.TLS.Cert,
+		KeyFile:           cfg.TLS.Key,
+		ClientCAFile:      cfg.TLS.ClientCA,
+		RequireClientCert: cfg.TLS.RequireClientCert,
+	})
 	if err != nil {
-		log.Fatalf("Failed to listen : %v", err)
+		log.Fatalf("Failed to load TLS certificates : %v", err)
 	}
+	return reloader.TLSConfig()
+}
 
-	log.Printf("Listening on %s\n", address)
-	grpcServer := grpc.NewServer()
-	pb.RegisterBookingServiceServer(grpcServer, api.NewBookingServiceServer())
-	if err := grpcServer.Serve(listen); err != nil {
-		log.Fatalf("Failed to serve : %v\n", err)
+// serverOptions returns the handler tracing every call and the interceptors
+// recording metrics, when observer is set, logging every call, rejecting calls
+// until gate is ready and enforcing authentication. Authentication is
+// left out when anonymous access was explicitly allowed. The options leave out
+// transport credentials, so that they can be shared with the gateway.
+func serverOptions(cfg *config.Config, gate *readiness.Gate, observer *metrics.Metrics) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.Limits.MaxRecvMessageBytes),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	}
+	if observer != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(observer.UnaryInterceptor()),
+			grpc.ChainStreamInterceptor(observer.StreamInterceptor()),
+		)
+	}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(logging.UnaryInterceptor(slog.Default()), gate.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(logging.StreamInterceptor(slog.Default()), gate.StreamInterceptor()),
+	)
+	var authOpts []auth.Option
+	if cfg.TLS.ClientCA != "" {
+		authOpts = append(authOpts, auth.WithClientCertificates())
 	}
+
+	if cfg.Auth.JWKS != "" {
+		keys, err := auth.LoadKeySet(cfg.Auth.JWKS)
+		if err != nil {
+			log.Fatalf("Failed to load JWKS : %v", err)
+		}
+		authOpts = append(authOpts, auth.WithKeySet(keys), auth.WithIssuer(cfg.Auth.JWTIssuer), auth.WithAudience(cfg.Auth.JWTAudience))
+	}
+	if cfg.Auth.APIKeys != "" {
+		keys, err := auth.LoadAPIKeys(cfg.Auth.APIKeys)
+		if err != nil {
+			log.Fatalf("Failed to load API keys : %v", err)
+		}
+		authOpts = append(authOpts, auth.WithAPIKeys(keys))
+	}
+	if len(authOpts) == 0 {
+		log.Printf("Authentication is disabled, every caller can use every RPC\n")
+	} else {
+		public := []string{readiness.HealthService}
+		if cfg.Reflection {
+			public = append(public, apidesc.ReflectionServices...)
+		}
+		authenticator := auth.NewAuthenticator(append(authOpts, auth.WithPolicy(api.Policy), auth.WithPublicServices(public...))...)
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()),
+			grpc.ChainStreamInterceptor(authenticator.StreamInterceptor()),
+		)
+	}
+	// Rate limits apply after authentication, so that callers are told apart
+	// by identity.
+	limiter := newLimiter(cfg.RateLimit)
+	return append(opts,
+		grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(limiter.StreamInterceptor()),
+	)
+}
+
+// newLimiter returns the rate limiter configured by cfg, which was validated.
+func newLimiter(cfg config.RateLimit) *ratelimit.Limiter {
+	opts := []ratelimit.Option{
+		ratelimit.WithExemptServices(readiness.HealthService),
+		ratelimit.WithExemptServices(apidesc.ReflectionServices...),
+	}
+	if rate, _ := cfg.DefaultRate(); rate != nil {
+		opts = append(opts, ratelimit.WithDefault(ratelimit.Limit{Rate: xrate.Limit(rate.PerSecond), Burst: rate.Burst}))
+	}
+	rates, _ := cfg.MethodRates()
+	for method, rate := range rates {
+		opts = append(opts, ratelimit.WithMethodLimit(method, ratelimit.Limit{Rate: xrate.Limit(rate.PerSecond), Burst: rate.Burst}))
+	}
+	return ratelimit.NewLimiter(opts...)
+}
+
+// serveMetrics serves observer at /metrics on the configured address until the
+// returned server is closed.
+func serveMetrics(cfg config.Metrics, observer *metrics.Metrics) (*http.Server, error) {
+	listen, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", observer.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("Serving metrics on http://%s/metrics\n", listen.Addr())
+	go func() {
+		if err := server.Serve(listen); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Failed to serve metrics : %v\n", err)
+		}
+	}()
+	return server, nil
+}
+
+// registerBookingServices serves the bookings of bookingServer on server
+// through both versions of the booking API.
+func registerBookingServices(server *grpc.Server, bookingServer *api.BookingServiceServer) {
+	pb.RegisterBookingServiceServer(server, bookingServer)
+	bookingv2.RegisterBookingServiceServer(server, api.NewBookingServiceV2Server(bookingServer))
+}
+
+// serveGateway serves the HTTP/JSON and gRPC-Web API on the configured address, calling
+// bookingServer through backend, until the returned server is stopped. Serving
+// errors are sent to served.
+func serveGateway(cfg config.Gateway, tlsConfig *tls.Config, backend *grpc.Server, bookingServer *api.BookingServiceServer, served chan<- error) (*gateway.Server, error) {
+	registerBookingServices(backend, bookingServer)
+	server, err := gateway.New(backend, tlsConfig, gateway.WithAllowedOrigins(cfg.AllowedOrigins()...))
+	if err != nil {
+		return nil, err
+	}
+	listen, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		server.Stop()
+		return nil, err
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	log.Printf("Serving the HTTP/JSON and gRPC-Web API on %s://%s, with its OpenAPI documents at %s and %s\n", scheme, listen.Addr(), gateway.OpenAPIPath, gateway.OpenAPIV2Path)
+	go func() {
+		if err := server.Serve(listen); err != nil {
+			served <- err
+		}
+	}()
+	return server, nil
+}
+
+// runInBackground runs run until the returned function is called, which
+// cancels its context and waits for it to return.
+func runInBackground(run func(context.Context)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		run(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// newNotifier builds the notifier sending on the configured channels.
+func newNotifier(cfg config.Notify) (*notify.Notifier, error) {
+	templates := notify.DefaultTemplates()
+	if cfg.Templates != "" {
+		var err error
+		if templates, err = notify.LoadTemplates(cfg.Templates); err != nil {
+			return nil, err
+		}
+	}
+	var channels []notify.Channel
+	for _, name := range cfg.ChannelNames() {
+		switch name {
+		case "smtp":
+			channels = append(channels, &notify.SMTP{Addr: cfg.SMTPAddr, From: cfg.SMTPFrom, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword})
+		case "sms":
+			channels = append(channels, &notify.SMS{URL: cfg.SMSURL, Token: cfg.SMSToken})
+		case "log":
+			channels = append(channels, &notify.Log{})
+		}
+	}
+	retry := events.RetryPolicy{MaxAttempts: cfg.MaxAttempts, InitialBackoff: cfg.InitialBackoff, MaxBackoff: cfg.MaxBackoff}
+	return notify.NewNotifier(templates, channels, notify.WithRetryPolicy(retry)), nil
+}
+
+// Exit codes of the server.
+const (
+	exitOK           = 0 // drained and saved every booking
+	exitStartup      = 1 // invalid configuration or failed startup
+	exitDrainTimeout = 2 // in-flight calls were cut off at the shutdown timeout
+	exitFlushFailed  = 3 // bookings could not be saved on shutdown
+	exitServeFailed  = 4 // the listener failed while serving
+)
+
+// flushPeriodically saves changed bookings every interval until done is closed.
+func flushPeriodically(server *api.BookingServiceServer, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := server.Flush(context.Background()); err != nil {
+				log.Printf("Failed to save bookings : %v\n", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// drainable is a server that can stop gracefully, such as the gRPC server and
+// the gateway.
+type drainable interface {
+	GracefulStop()
+	Stop()
+}
+
+// shutdown stops accepting calls, waits up to timeout for in-flight calls to
+// finish on every server, cancelling them after that, stops the background
+// work, such as delivering events, and saves the bookings.
+func shutdown(servers []drainable, bookingServer *api.BookingServiceServer, stopBackground func(), timeout time.Duration) int {
+	code := exitOK
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server drainable) {
+			defer wg.Done()
+			server.GracefulStop()
+		}(server)
+	}
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		log.Printf("Drained all in-flight calls\n")
+	case <-time.After(timeout):
+		log.Printf("In-flight calls did not finish within %s, cancelling them\n", timeout)
+		for _, server := range servers {
+			server.Stop()
+		}
+		<-drained
+		code = exitDrainTimeout
+	}
+	stopBackground()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := bookingServer.Flush(ctx); err != nil {
+		log.Printf("Failed to save bookings : %v\n", err)
+		return exitFlushFailed
+	}
+	return code
+}
+
+// run serves until SIGINT or SIGTERM and returns the process exit code.
+func run() int {
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		log.Printf("Invalid configuration : %v", err)
+		return exitStartup
+	}
+	setUpLogging(cfg.Log)
+	stopTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "book-my-seat-server",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Printf("Failed to set up tracing : %v", err)
+		return exitStartup
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := stopTracing(ctx); err != nil {
+			log.Printf("Failed to export the remaining spans : %v\n", err)
+		}
+	}()
+
+	serviceOpts, err := serviceOptions(cfg)
+	if err != nil {
+		log.Printf("Failed to set up the booking service : %v", err)
+		return exitStartup
+	}
+	var outbox *events.Outbox
+	if cfg.Events.Webhooks != "" {
+		webhooks, err := events.LoadWebhooks(cfg.Events.Webhooks)
+		if err != nil {
+			log.Printf("Failed to load webhooks : %v", err)
+			return exitStartup
+		}
+		outbox = events.NewOutbox(webhooks)
+		serviceOpts = append(serviceOpts, api.WithOutbox(outbox))
+	}
+	var notifier *notify.Notifier
+	if len(cfg.Notify.ChannelNames()) > 0 {
+		notifier, err = newNotifier(cfg.Notify)
+		if err != nil {
+			log.Printf("Failed to set up notifications : %v", err)
+			return exitStartup
+		}
+		serviceOpts = append(serviceOpts, api.WithNotifier(notifier))
+	}
+	bookingServer := api.NewBookingServiceServer(serviceOpts...)
+
+	listen, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		log.Printf("Failed to listen : %v", err)
+		return exitStartup
+	}
+
+	var observer *metrics.Metrics
+	if cfg.Metrics.Listen != "" {
+		observer = metrics.New()
+		if err := observer.RegisterBookings(bookingServer); err != nil {
+			log.Printf("Failed to register booking metrics : %v", err)
+			return exitStartup
+		}
+		metricsServer, err := serveMetrics(cfg.Metrics, observer)
+		if err != nil {
+			log.Printf("Failed to serve metrics : %v", err)
+			return exitStartup
+		}
+		defer metricsServer.Close()
+	}
+
+	// Register for signals before serving so that none is missed.
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	// Serve health checks right away, but reject booking calls with Unavailable
+	// until the bookings are loaded.
+	gate := readiness.NewGate(pb.BookingService_ServiceDesc.ServiceName, bookingv2.BookingService_ServiceDesc.ServiceName)
+	log.Printf("Listening on %s\n", listen.Addr())
+	tlsConfig := serverTLS(cfg)
+	opts := serverOptions(cfg, gate, observer)
+	grpcOpts := opts
+	if tlsConfig != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(grpcOpts...)
+	registerBookingServices(grpcServer, bookingServer)
+	gate.Register(grpcServer)
+	if cfg.Reflection {
+		if err := apidesc.RegisterReflection(grpcServer); err != nil {
+			log.Printf("Failed to register reflection : %v", err)
+			return exitStartup
+		}
+	}
+	servers := []drainable{grpcServer}
+	served := make(chan error, 2)
+	go func() {
+		served <- grpcServer.Serve(listen)
+	}()
+
+	if cfg.Gateway.Listen != "" {
+		gatewayServer, err := serveGateway(cfg.Gateway, tlsConfig, grpc.NewServer(opts...), bookingServer, served)
+		if err != nil {
+			log.Printf("Failed to serve the gateway : %v", err)
+			grpcServer.Stop()
+			return exitStartup
+		}
+		servers = append(servers, gatewayServer)
+	}
+
+	if err := bookingServer.Restore(context.Background()); err != nil {
+		log.Printf("Failed to load bookings : %v", err)
+		for _, server := range servers {
+			server.Stop()
+		}
+		return exitStartup
+	}
+	gate.SetReady()
+	log.Printf("Loaded bookings, ready to serve\n")
+
+	var stops []func()
+	if outbox != nil {
+		dispatcher := events.NewDispatcher(outbox, events.WithRetryPolicy(events.RetryPolicy{
+			MaxAttempts:    cfg.Events.MaxAttempts,
+			InitialBackoff: cfg.Events.InitialBackoff,
+			MaxBackoff:     cfg.Events.MaxBackoff,
+		}))
+		stops = append(stops, runInBackground(dispatcher.Run))
+	}
+	if notifier != nil {
+		stops = append(stops, runInBackground(notifier.Run))
+	}
+	stopBackground := func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+
+	flushDone := make(chan struct{})
+	go flushPeriodically(bookingServer, cfg.Storage.FlushInterval, flushDone)
+	defer close(flushDone)
+
+	select {
+	case err := <-served:
+		log.Printf("Failed to serve : %v\n", err)
+		stopBackground()
+		if err := bookingServer.Flush(context.Background()); err != nil {
+			log.Printf("Failed to save bookings : %v\n", err)
+		}
+		return exitServeFailed
+	case <-signals.Done():
+	}
+	// Restore the default behaviour so that a second signal kills the server
+	// without waiting for the drain.
+	stopSignals()
+	gate.Drain()
+	log.Printf("Shutting down, draining in-flight calls for up to %s\n", cfg.ShutdownTimeout)
+	return shutdown(servers, bookingServer, stopBackground, cfg.ShutdownTimeout)
+}
+
+func main() {
+	os.Exit(run())
 }
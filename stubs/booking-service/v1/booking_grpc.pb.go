@@ -27,6 +27,16 @@ type BookingServiceClient interface {
 	GetUsersAndSeatAllocated(ctx context.Context, in *GetUsersAndSeatAllocatedRequest, opts ...grpc.CallOption) (*GetUsersAndSeatAllocatedResponse, error)
 	RemoveUser(ctx context.Context, in *RemoveUserRequest, opts ...grpc.CallOption) (*RemoveUserResponse, error)
 	ModifyUserSeat(ctx context.Context, in *ModifyUserSeatRequest, opts ...grpc.CallOption) (*ModifyUserSeatResponse, error)
+	ListTickets(ctx context.Context, in *ListTicketsRequest, opts ...grpc.CallOption) (*ListTicketsResponse, error)
+	SearchPassengers(ctx context.Context, in *SearchPassengersRequest, opts ...grpc.CallOption) (*SearchPassengersResponse, error)
+	ImportBookings(ctx context.Context, opts ...grpc.CallOption) (BookingService_ImportBookingsClient, error)
+	ExportManifest(ctx context.Context, in *ExportManifestRequest, opts ...grpc.CallOption) (BookingService_ExportManifestClient, error)
+	RenderReceipt(ctx context.Context, in *RenderReceiptRequest, opts ...grpc.CallOption) (*RenderReceiptResponse, error)
+	VerifyTicket(ctx context.Context, in *VerifyTicketRequest, opts ...grpc.CallOption) (*VerifyTicketResponse, error)
+	CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*CheckInResponse, error)
+	ScanTicket(ctx context.Context, in *ScanTicketRequest, opts ...grpc.CallOption) (*ScanTicketResponse, error)
+	GetBoardingReport(ctx context.Context, in *GetBoardingReportRequest, opts ...grpc.CallOption) (*GetBoardingReportResponse, error)
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
 }
 
 type bookingServiceClient struct {
@@ -82,6 +92,144 @@ func (c *bookingServiceClient) ModifyUserSeat(ctx context.Context, in *ModifyUse
 	return out, nil
 }
 
+func (c *bookingServiceClient) ListTickets(ctx context.Context, in *ListTicketsRequest, opts ...grpc.CallOption) (*ListTicketsResponse, error) {
+	out := new(ListTicketsResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/ListTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) SearchPassengers(ctx context.Context, in *SearchPassengersRequest, opts ...grpc.CallOption) (*SearchPassengersResponse, error) {
+	out := new(SearchPassengersResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/SearchPassengers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) ImportBookings(ctx context.Context, opts ...grpc.CallOption) (BookingService_ImportBookingsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BookingService_ServiceDesc.Streams[0], "/BookingService.BookingService/ImportBookings", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookingServiceImportBookingsClient{stream}
+	return x, nil
+}
+
+type BookingService_ImportBookingsClient interface {
+	Send(*ImportBookingsRequest) error
+	CloseAndRecv() (*ImportBookingsResponse, error)
+	grpc.ClientStream
+}
+
+type bookingServiceImportBookingsClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookingServiceImportBookingsClient) Send(m *ImportBookingsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *bookingServiceImportBookingsClient) CloseAndRecv() (*ImportBookingsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportBookingsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bookingServiceClient) ExportManifest(ctx context.Context, in *ExportManifestRequest, opts ...grpc.CallOption) (BookingService_ExportManifestClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BookingService_ServiceDesc.Streams[1], "/BookingService.BookingService/ExportManifest", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookingServiceExportManifestClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BookingService_ExportManifestClient interface {
+	Recv() (*ExportManifestResponse, error)
+	grpc.ClientStream
+}
+
+type bookingServiceExportManifestClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookingServiceExportManifestClient) Recv() (*ExportManifestResponse, error) {
+	m := new(ExportManifestResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bookingServiceClient) RenderReceipt(ctx context.Context, in *RenderReceiptRequest, opts ...grpc.CallOption) (*RenderReceiptResponse, error) {
+	out := new(RenderReceiptResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/RenderReceipt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) VerifyTicket(ctx context.Context, in *VerifyTicketRequest, opts ...grpc.CallOption) (*VerifyTicketResponse, error) {
+	out := new(VerifyTicketResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/VerifyTicket", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*CheckInResponse, error) {
+	out := new(CheckInResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/CheckIn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) ScanTicket(ctx context.Context, in *ScanTicketRequest, opts ...grpc.CallOption) (*ScanTicketResponse, error) {
+	out := new(ScanTicketResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/ScanTicket", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) GetBoardingReport(ctx context.Context, in *GetBoardingReportRequest, opts ...grpc.CallOption) (*GetBoardingReportResponse, error) {
+	out := new(GetBoardingReportResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/GetBoardingReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, "/BookingService.BookingService/ListAuditEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BookingServiceServer is the server API for BookingService service.
 // All implementations must embed UnimplementedBookingServiceServer
 // for forward compatibility
@@ -91,6 +239,16 @@ type BookingServiceServer interface {
 	GetUsersAndSeatAllocated(context.Context, *GetUsersAndSeatAllocatedRequest) (*GetUsersAndSeatAllocatedResponse, error)
 	RemoveUser(context.Context, *RemoveUserRequest) (*RemoveUserResponse, error)
 	ModifyUserSeat(context.Context, *ModifyUserSeatRequest) (*ModifyUserSeatResponse, error)
+	ListTickets(context.Context, *ListTicketsRequest) (*ListTicketsResponse, error)
+	SearchPassengers(context.Context, *SearchPassengersRequest) (*SearchPassengersResponse, error)
+	ImportBookings(BookingService_ImportBookingsServer) error
+	ExportManifest(*ExportManifestRequest, BookingService_ExportManifestServer) error
+	RenderReceipt(context.Context, *RenderReceiptRequest) (*RenderReceiptResponse, error)
+	VerifyTicket(context.Context, *VerifyTicketRequest) (*VerifyTicketResponse, error)
+	CheckIn(context.Context, *CheckInRequest) (*CheckInResponse, error)
+	ScanTicket(context.Context, *ScanTicketRequest) (*ScanTicketResponse, error)
+	GetBoardingReport(context.Context, *GetBoardingReportRequest) (*GetBoardingReportResponse, error)
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
 	mustEmbedUnimplementedBookingServiceServer()
 }
 
@@ -113,6 +271,36 @@ func (UnimplementedBookingServiceServer) RemoveUser(context.Context, *RemoveUser
 func (UnimplementedBookingServiceServer) ModifyUserSeat(context.Context, *ModifyUserSeatRequest) (*ModifyUserSeatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ModifyUserSeat not implemented")
 }
+func (UnimplementedBookingServiceServer) ListTickets(context.Context, *ListTicketsRequest) (*ListTicketsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTickets not implemented")
+}
+func (UnimplementedBookingServiceServer) SearchPassengers(context.Context, *SearchPassengersRequest) (*SearchPassengersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchPassengers not implemented")
+}
+func (UnimplementedBookingServiceServer) ImportBookings(BookingService_ImportBookingsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportBookings not implemented")
+}
+func (UnimplementedBookingServiceServer) ExportManifest(*ExportManifestRequest, BookingService_ExportManifestServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportManifest not implemented")
+}
+func (UnimplementedBookingServiceServer) RenderReceipt(context.Context, *RenderReceiptRequest) (*RenderReceiptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderReceipt not implemented")
+}
+func (UnimplementedBookingServiceServer) VerifyTicket(context.Context, *VerifyTicketRequest) (*VerifyTicketResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyTicket not implemented")
+}
+func (UnimplementedBookingServiceServer) CheckIn(context.Context, *CheckInRequest) (*CheckInResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckIn not implemented")
+}
+func (UnimplementedBookingServiceServer) ScanTicket(context.Context, *ScanTicketRequest) (*ScanTicketResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScanTicket not implemented")
+}
+func (UnimplementedBookingServiceServer) GetBoardingReport(context.Context, *GetBoardingReportRequest) (*GetBoardingReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBoardingReport not implemented")
+}
+func (UnimplementedBookingServiceServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
 func (UnimplementedBookingServiceServer) mustEmbedUnimplementedBookingServiceServer() {}
 
 // UnsafeBookingServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -216,6 +404,197 @@ func _BookingService_ModifyUserSeat_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookingService_ListTickets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTicketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).ListTickets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/ListTickets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).ListTickets(ctx, req.(*ListTicketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_SearchPassengers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchPassengersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).SearchPassengers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/SearchPassengers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).SearchPassengers(ctx, req.(*SearchPassengersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_ImportBookings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BookingServiceServer).ImportBookings(&bookingServiceImportBookingsServer{stream})
+}
+
+type BookingService_ImportBookingsServer interface {
+	SendAndClose(*ImportBookingsResponse) error
+	Recv() (*ImportBookingsRequest, error)
+	grpc.ServerStream
+}
+
+type bookingServiceImportBookingsServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookingServiceImportBookingsServer) SendAndClose(m *ImportBookingsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *bookingServiceImportBookingsServer) Recv() (*ImportBookingsRequest, error) {
+	m := new(ImportBookingsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BookingService_ExportManifest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportManifestRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookingServiceServer).ExportManifest(m, &bookingServiceExportManifestServer{stream})
+}
+
+type BookingService_ExportManifestServer interface {
+	Send(*ExportManifestResponse) error
+	grpc.ServerStream
+}
+
+type bookingServiceExportManifestServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookingServiceExportManifestServer) Send(m *ExportManifestResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BookingService_RenderReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).RenderReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/RenderReceipt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).RenderReceipt(ctx, req.(*RenderReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_VerifyTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).VerifyTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/VerifyTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).VerifyTicket(ctx, req.(*VerifyTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_CheckIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).CheckIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/CheckIn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).CheckIn(ctx, req.(*CheckInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_ScanTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).ScanTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/ScanTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).ScanTicket(ctx, req.(*ScanTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_GetBoardingReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBoardingReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).GetBoardingReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/GetBoardingReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).GetBoardingReport(ctx, req.(*GetBoardingReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/BookingService.BookingService/ListAuditEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BookingService_ServiceDesc is the grpc.ServiceDesc for BookingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -243,7 +622,50 @@ var BookingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ModifyUserSeat",
 			Handler:    _BookingService_ModifyUserSeat_Handler,
 		},
+		{
+			MethodName: "ListTickets",
+			Handler:    _BookingService_ListTickets_Handler,
+		},
+		{
+			MethodName: "SearchPassengers",
+			Handler:    _BookingService_SearchPassengers_Handler,
+		},
+		{
+			MethodName: "RenderReceipt",
+			Handler:    _BookingService_RenderReceipt_Handler,
+		},
+		{
+			MethodName: "VerifyTicket",
+			Handler:    _BookingService_VerifyTicket_Handler,
+		},
+		{
+			MethodName: "CheckIn",
+			Handler:    _BookingService_CheckIn_Handler,
+		},
+		{
+			MethodName: "ScanTicket",
+			Handler:    _BookingService_ScanTicket_Handler,
+		},
+		{
+			MethodName: "GetBoardingReport",
+			Handler:    _BookingService_GetBoardingReport_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _BookingService_ListAuditEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportBookings",
+			Handler:       _BookingService_ImportBookings_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportManifest",
+			Handler:       _BookingService_ExportManifest_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "booking-service/v1/booking.proto",
 }
@@ -0,0 +1,376 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.24.3
+// source: bookmyseat/booking/v2/booking.proto
+
+package bookingv2
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// BookingServiceClient is the client API for BookingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookingServiceClient interface {
+	// GetDeparture returns a departure and how many of its seats are free.
+	GetDeparture(ctx context.Context, in *GetDepartureRequest, opts ...grpc.CallOption) (*Departure, error)
+	// ListDepartures lists the departures that can be booked.
+	ListDepartures(ctx context.Context, in *ListDeparturesRequest, opts ...grpc.CallOption) (*ListDeparturesResponse, error)
+	// ListSeats lists the seats of a departure, in section and number order.
+	ListSeats(ctx context.Context, in *ListSeatsRequest, opts ...grpc.CallOption) (*ListSeatsResponse, error)
+	// CreateTicket books the seat of the ticket for its passenger. A passenger
+	// holds at most one ticket.
+	CreateTicket(ctx context.Context, in *CreateTicketRequest, opts ...grpc.CallOption) (*Ticket, error)
+	GetTicket(ctx context.Context, in *GetTicketRequest, opts ...grpc.CallOption) (*Ticket, error)
+	// ListTickets lists tickets in section and seat order.
+	ListTickets(ctx context.Context, in *ListTicketsRequest, opts ...grpc.CallOption) (*ListTicketsResponse, error)
+	// UpdateTicket moves the passenger to another seat of the same departure,
+	// the only change a ticket allows.
+	UpdateTicket(ctx context.Context, in *UpdateTicketRequest, opts ...grpc.CallOption) (*Ticket, error)
+	// DeleteTicket cancels a ticket, freeing its seat.
+	DeleteTicket(ctx context.Context, in *DeleteTicketRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type bookingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookingServiceClient(cc grpc.ClientConnInterface) BookingServiceClient {
+	return &bookingServiceClient{cc}
+}
+
+func (c *bookingServiceClient) GetDeparture(ctx context.Context, in *GetDepartureRequest, opts ...grpc.CallOption) (*Departure, error) {
+	out := new(Departure)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/GetDeparture", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) ListDepartures(ctx context.Context, in *ListDeparturesRequest, opts ...grpc.CallOption) (*ListDeparturesResponse, error) {
+	out := new(ListDeparturesResponse)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/ListDepartures", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) ListSeats(ctx context.Context, in *ListSeatsRequest, opts ...grpc.CallOption) (*ListSeatsResponse, error) {
+	out := new(ListSeatsResponse)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/ListSeats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) CreateTicket(ctx context.Context, in *CreateTicketRequest, opts ...grpc.CallOption) (*Ticket, error) {
+	out := new(Ticket)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/CreateTicket", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) GetTicket(ctx context.Context, in *GetTicketRequest, opts ...grpc.CallOption) (*Ticket, error) {
+	out := new(Ticket)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/GetTicket", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) ListTickets(ctx context.Context, in *ListTicketsRequest, opts ...grpc.CallOption) (*ListTicketsResponse, error) {
+	out := new(ListTicketsResponse)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/ListTickets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) UpdateTicket(ctx context.Context, in *UpdateTicketRequest, opts ...grpc.CallOption) (*Ticket, error) {
+	out := new(Ticket)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/UpdateTicket", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookingServiceClient) DeleteTicket(ctx context.Context, in *DeleteTicketRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/bookmyseat.booking.v2.BookingService/DeleteTicket", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BookingServiceServer is the server API for BookingService service.
+// All implementations must embed UnimplementedBookingServiceServer
+// for forward compatibility
+type BookingServiceServer interface {
+	// GetDeparture returns a departure and how many of its seats are free.
+	GetDeparture(context.Context, *GetDepartureRequest) (*Departure, error)
+	// ListDepartures lists the departures that can be booked.
+	ListDepartures(context.Context, *ListDeparturesRequest) (*ListDeparturesResponse, error)
+	// ListSeats lists the seats of a departure, in section and number order.
+	ListSeats(context.Context, *ListSeatsRequest) (*ListSeatsResponse, error)
+	// CreateTicket books the seat of the ticket for its passenger. A passenger
+	// holds at most one ticket.
+	CreateTicket(context.Context, *CreateTicketRequest) (*Ticket, error)
+	GetTicket(context.Context, *GetTicketRequest) (*Ticket, error)
+	// ListTickets lists tickets in section and seat order.
+	ListTickets(context.Context, *ListTicketsRequest) (*ListTicketsResponse, error)
+	// UpdateTicket moves the passenger to another seat of the same departure,
+	// the only change a ticket allows.
+	UpdateTicket(context.Context, *UpdateTicketRequest) (*Ticket, error)
+	// DeleteTicket cancels a ticket, freeing its seat.
+	DeleteTicket(context.Context, *DeleteTicketRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedBookingServiceServer()
+}
+
+// UnimplementedBookingServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBookingServiceServer struct {
+}
+
+func (UnimplementedBookingServiceServer) GetDeparture(context.Context, *GetDepartureRequest) (*Departure, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeparture not implemented")
+}
+func (UnimplementedBookingServiceServer) ListDepartures(context.Context, *ListDeparturesRequest) (*ListDeparturesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDepartures not implemented")
+}
+func (UnimplementedBookingServiceServer) ListSeats(context.Context, *ListSeatsRequest) (*ListSeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSeats not implemented")
+}
+func (UnimplementedBookingServiceServer) CreateTicket(context.Context, *CreateTicketRequest) (*Ticket, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTicket not implemented")
+}
+func (UnimplementedBookingServiceServer) GetTicket(context.Context, *GetTicketRequest) (*Ticket, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTicket not implemented")
+}
+func (UnimplementedBookingServiceServer) ListTickets(context.Context, *ListTicketsRequest) (*ListTicketsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTickets not implemented")
+}
+func (UnimplementedBookingServiceServer) UpdateTicket(context.Context, *UpdateTicketRequest) (*Ticket, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTicket not implemented")
+}
+func (UnimplementedBookingServiceServer) DeleteTicket(context.Context, *DeleteTicketRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTicket not implemented")
+}
+func (UnimplementedBookingServiceServer) mustEmbedUnimplementedBookingServiceServer() {}
+
+// UnsafeBookingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookingServiceServer will
+// result in compilation errors.
+type UnsafeBookingServiceServer interface {
+	mustEmbedUnimplementedBookingServiceServer()
+}
+
+func RegisterBookingServiceServer(s grpc.ServiceRegistrar, srv BookingServiceServer) {
+	s.RegisterService(&BookingService_ServiceDesc, srv)
+}
+
+func _BookingService_GetDeparture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDepartureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).GetDeparture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/GetDeparture",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).GetDeparture(ctx, req.(*GetDepartureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_ListDepartures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeparturesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).ListDepartures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/ListDepartures",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).ListDepartures(ctx, req.(*ListDeparturesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_ListSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).ListSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/ListSeats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).ListSeats(ctx, req.(*ListSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_CreateTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).CreateTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/CreateTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).CreateTicket(ctx, req.(*CreateTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_GetTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).GetTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/GetTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).GetTicket(ctx, req.(*GetTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_ListTickets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTicketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).ListTickets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/ListTickets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).ListTickets(ctx, req.(*ListTicketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_UpdateTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).UpdateTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/UpdateTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).UpdateTicket(ctx, req.(*UpdateTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookingService_DeleteTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookingServiceServer).DeleteTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bookmyseat.booking.v2.BookingService/DeleteTicket",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookingServiceServer).DeleteTicket(ctx, req.(*DeleteTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BookingService_ServiceDesc is the grpc.ServiceDesc for BookingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BookingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bookmyseat.booking.v2.BookingService",
+	HandlerType: (*BookingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDeparture",
+			Handler:    _BookingService_GetDeparture_Handler,
+		},
+		{
+			MethodName: "ListDepartures",
+			Handler:    _BookingService_ListDepartures_Handler,
+		},
+		{
+			MethodName: "ListSeats",
+			Handler:    _BookingService_ListSeats_Handler,
+		},
+		{
+			MethodName: "CreateTicket",
+			Handler:    _BookingService_CreateTicket_Handler,
+		},
+		{
+			MethodName: "GetTicket",
+			Handler:    _BookingService_GetTicket_Handler,
+		},
+		{
+			MethodName: "ListTickets",
+			Handler:    _BookingService_ListTickets_Handler,
+		},
+		{
+			MethodName: "UpdateTicket",
+			Handler:    _BookingService_UpdateTicket_Handler,
+		},
+		{
+			MethodName: "DeleteTicket",
+			Handler:    _BookingService_DeleteTicket_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "bookmyseat/booking/v2/booking.proto",
+}